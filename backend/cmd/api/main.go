@@ -1,64 +1,27 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
-	"project_management/internal/handlers"
+	"os/signal"
+	"project_management/internal/mail"
 	"project_management/internal/middleware"
 	"project_management/internal/repository"
+	"project_management/internal/scheduler"
+	"project_management/internal/storage"
+	"project_management/internal/stream"
+	"project_management/internal/workflow"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
-// setupRoutes 设置API路由
-func setupRoutes(router *gin.Engine) {
-	// 公开路由
-	public := router.Group("/api")
-	{
-		// 认证相关路由
-		auth := public.Group("/auth")
-		{
-			auth.POST("/login", handlers.Login)
-			auth.POST("/register", handlers.Register)
-			auth.POST("/refresh", handlers.RefreshToken)
-			auth.POST("/logout", handlers.Logout)
-		}
-	}
-
-	// 受保护路由
-	protected := router.Group("/api")
-	protected.Use(middleware.AuthMiddleware())
-	{
-		// 用户相关路由
-		user := protected.Group("/user")
-		{
-			user.GET("/me", handlers.GetCurrentUser)
-		}
-
-		// 任务相关路由
-		tasks := protected.Group("/tasks")
-		{
-			tasks.GET("", handlers.GetAllTasks)
-			tasks.GET("/:id", handlers.GetTaskByID)
-			tasks.POST("", handlers.CreateTask)
-			tasks.PUT("/:id", handlers.UpdateTask)
-			tasks.DELETE("/:id", handlers.DeleteTask)
-		}
-
-		// 里程碑相关路由
-		milestones := protected.Group("/milestones")
-		{
-			milestones.GET("", handlers.GetAllMilestones)
-			milestones.GET("/:id", handlers.GetMilestoneByID)
-			milestones.POST("", handlers.CreateMilestone)
-			milestones.PUT("/:id", handlers.UpdateMilestone)
-			milestones.DELETE("/:id", handlers.DeleteMilestone)
-		}
-	}
-}
-
 func main() {
 	// 加载环境变量
 	err := godotenv.Load()
@@ -69,6 +32,15 @@ func main() {
 	// 初始化数据库
 	repository.InitDB()
 
+	// 加载任务状态流转规则
+	workflow.Init()
+
+	// 初始化附件存储
+	storage.Init()
+
+	// 初始化邮件发送方式
+	mail.Init()
+
 	// 设置Gin模式
 	gin.SetMode(gin.ReleaseMode)
 	if os.Getenv("GIN_MODE") != "" {
@@ -77,7 +49,7 @@ func main() {
 
 	// 创建Gin实例
 	router := gin.Default()
-	
+
 	// 添加CORS中间件
 	router.Use(middleware.CorsMiddleware())
 
@@ -90,9 +62,46 @@ func main() {
 		port = "8080"
 	}
 
+	// 启动定时任务
+	sched := scheduler.New()
+	sched.Every("标记逾期任务", scheduler.IntervalFromEnv("OVERDUE_CHECK_INTERVAL", 10*time.Minute), scheduler.MarkOverdueTasks)
+	sched.Every("清理过期令牌", scheduler.IntervalFromEnv("TOKEN_CLEANUP_INTERVAL", time.Hour), scheduler.CleanupExpiredTokens)
+	sched.Every("清理回收站", scheduler.IntervalFromEnv("TRASH_PURGE_INTERVAL", time.Hour), scheduler.PurgeTrash)
+	sched.Every("投递Webhook", scheduler.IntervalFromEnv("WEBHOOK_DELIVERY_INTERVAL", 5*time.Second), scheduler.DeliverWebhooks)
+	sched.Every("清理Webhook投递记录", scheduler.IntervalFromEnv("WEBHOOK_CLEANUP_INTERVAL", time.Hour), scheduler.CleanupWebhookDeliveries)
+	sched.Every("发送截止提醒", scheduler.IntervalFromEnv("DEADLINE_REMINDER_INTERVAL", 15*time.Minute), scheduler.DeadlineReminders)
+	sched.Every("发送邮件", scheduler.IntervalFromEnv("EMAIL_DELIVERY_INTERVAL", 10*time.Second), scheduler.SendEmails)
+	sched.Every("发送邮件摘要", scheduler.IntervalFromEnv("DIGEST_CHECK_INTERVAL", 30*time.Minute), scheduler.SendDigests)
+	sched.Every("清理邮件记录", scheduler.IntervalFromEnv("EMAIL_CLEANUP_INTERVAL", time.Hour), scheduler.CleanupEmailDeliveries)
+	sched.Start()
+
 	// 启动服务器
-	log.Printf("服务器启动在端口 %s", port)
-	if err := router.Run(fmt.Sprintf(":%s", port)); err != nil {
-		log.Fatalf("启动服务器失败: %v", err)
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%s", port),
+		Handler: router,
 	}
-}
\ No newline at end of file
+	go func() {
+		log.Printf("服务器启动在端口 %s", port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("启动服务器失败: %v", err)
+		}
+	}()
+
+	// 等待退出信号后优雅关闭
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	log.Println("正在关闭服务器...")
+	sched.Stop()
+
+	// 断开事件流的长连接，客户端重连后从最后的事件ID继续
+	stream.Current().Close()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("关闭服务器失败: %v", err)
+	}
+	log.Println("服务器已关闭")
+}
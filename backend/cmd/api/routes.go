@@ -3,6 +3,7 @@ package main
 import (
 	"project_management/internal/handlers"
 	"project_management/internal/middleware"
+	"project_management/internal/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,7 +20,23 @@ func setupRoutes(router *gin.Engine) {
 			auth.POST("/register", handlers.Register)
 			auth.POST("/refresh", handlers.RefreshToken)
 			auth.POST("/logout", handlers.Logout)
+			auth.POST("/restore", handlers.RestoreAccount)
 		}
+
+		// 日历订阅，使用地址中的订阅令牌认证
+		public.GET("/calendar/:token", handlers.GetCalendarFeed)
+
+		// 邮件中的验证和退订链接，通过令牌认证
+		public.GET("/email/verify/:token", handlers.VerifyEmail)
+		public.GET("/email/unsubscribe/:token", handlers.Unsubscribe)
+		public.POST("/email/unsubscribe/:token", handlers.Unsubscribe)
+	}
+
+	// 实时事件流，浏览器的EventSource可通过查询参数传递访问令牌
+	events := router.Group("/api")
+	events.Use(middleware.StreamAuthMiddleware())
+	{
+		events.GET("/events", handlers.StreamEvents)
 	}
 
 	// 受保护路由
@@ -30,26 +47,132 @@ func setupRoutes(router *gin.Engine) {
 		user := protected.Group("/user")
 		{
 			user.GET("/me", handlers.GetCurrentUser)
+			user.GET("/me/tasks", handlers.GetMyTasks)
+			user.DELETE("/me", handlers.DeleteAccount)
+			user.GET("/me/calendar", handlers.GetCalendarSubscription)
+			user.POST("/me/calendar", handlers.ResetCalendarSubscription)
+			user.DELETE("/me/calendar", handlers.DeleteCalendarSubscription)
+			user.GET("/me/email", handlers.GetEmail)
+			user.PUT("/me/email", handlers.UpdateEmail)
+			user.DELETE("/me/email", handlers.DeleteEmail)
+			user.POST("/me/email/verification", handlers.ResendEmailVerification)
+		}
+
+		// 统计相关路由
+		protected.GET("/stats", handlers.GetStats)
+
+		// 操作记录
+		protected.GET("/activity", handlers.GetActivityFeed)
+
+		// 甘特图时间线
+		protected.GET("/timeline", handlers.GetTimeline)
+
+		// 站内通知
+		notifications := protected.Group("/notifications")
+		{
+			notifications.GET("", handlers.GetNotifications)
+			notifications.GET("/unread-count", handlers.GetUnreadNotificationCount)
+			notifications.POST("/read-all", handlers.MarkAllNotificationsRead)
+			notifications.POST("/:id/read", handlers.MarkNotificationRead)
+			notifications.GET("/preferences", handlers.GetNotificationPreferences)
+			notifications.PUT("/preferences", handlers.UpdateNotificationPreferences)
+		}
+
+		// 回收站
+		trash := protected.Group("/trash")
+		{
+			trash.GET("", handlers.GetTrash)
+			trash.POST("/tasks/:id/restore", handlers.RestoreTask)
+			trash.POST("/milestones/:id/restore", handlers.RestoreMilestone)
+		}
+
+		// 任务状态流转规则
+		protected.GET("/workflow", handlers.GetWorkflow)
+
+		// 项目相关路由
+		projects := protected.Group("/projects")
+		{
+			canView := middleware.RequireProjectPermission(models.PermissionView)
+			canManage := middleware.RequireProjectPermission(models.PermissionManage)
+			canDelete := middleware.RequireProjectPermission(models.PermissionDeleteProject)
+
+			projects.GET("", handlers.GetAllProjects)
+			projects.GET("/:id", canView, handlers.GetProjectByID)
+			projects.POST("", handlers.CreateProject)
+			projects.PUT("/:id", canManage, handlers.UpdateProject)
+			projects.DELETE("/:id", canDelete, handlers.DeleteProject)
+			projects.GET("/:id/tasks", canView, handlers.GetProjectTasks)
+			projects.GET("/:id/milestones", canView, handlers.GetProjectMilestones)
+			projects.GET("/:id/stats", canView, handlers.GetProjectStats)
+			projects.GET("/:id/schedule", canView, handlers.GetProjectSchedule)
+
+			// 项目成员
+			projects.GET("/:id/members", canView, handlers.GetProjectMembers)
+			projects.POST("/:id/members", canManage, handlers.AddProjectMember)
+			projects.PUT("/:id/members/:userId", canManage, handlers.UpdateProjectMember)
+			projects.DELETE("/:id/members/:userId", canManage, handlers.RemoveProjectMember)
+
+			// 项目Webhook
+			projects.GET("/:id/webhooks", canManage, handlers.GetProjectWebhooks)
+			projects.POST("/:id/webhooks", canManage, handlers.CreateProjectWebhook)
+			projects.GET("/:id/webhooks/:webhookId", canManage, handlers.GetProjectWebhook)
+			projects.PUT("/:id/webhooks/:webhookId", canManage, handlers.UpdateProjectWebhook)
+			projects.DELETE("/:id/webhooks/:webhookId", canManage, handlers.DeleteProjectWebhook)
+			projects.POST("/:id/webhooks/:webhookId/ping", canManage, handlers.PingProjectWebhook)
+			projects.GET("/:id/webhooks/:webhookId/deliveries", canManage, handlers.GetWebhookDeliveries)
+			projects.GET("/:id/webhooks/:webhookId/deliveries/:deliveryId", canManage, handlers.GetWebhookDelivery)
+			projects.POST("/:id/webhooks/:webhookId/deliveries/:deliveryId/redeliver", canManage, handlers.RedeliverWebhookDelivery)
 		}
 
 		// 任务相关路由
 		tasks := protected.Group("/tasks")
 		{
 			tasks.GET("", handlers.GetAllTasks)
+			tasks.GET("/export", handlers.ExportTasks)
+			tasks.POST("/import", handlers.ImportTasks)
 			tasks.GET("/:id", handlers.GetTaskByID)
 			tasks.POST("", handlers.CreateTask)
+			tasks.POST("/bulk", handlers.BulkTasks)
 			tasks.PUT("/:id", handlers.UpdateTask)
+			tasks.PATCH("/:id", handlers.PatchTask)
 			tasks.DELETE("/:id", handlers.DeleteTask)
+			tasks.PUT("/:id/milestone", handlers.UpdateTaskMilestone)
+			tasks.GET("/:id/subtasks", handlers.GetTaskSubtasks)
+			tasks.PUT("/:id/parent", handlers.UpdateTaskParent)
+			tasks.GET("/:id/dependencies", handlers.GetTaskDependencies)
+			tasks.POST("/:id/dependencies", handlers.AddTaskDependency)
+			tasks.DELETE("/:id/dependencies/:blockerId", handlers.RemoveTaskDependency)
+			tasks.GET("/:id/activity", handlers.GetTaskActivity)
+			tasks.GET("/:id/comments", handlers.GetTaskComments)
+			tasks.POST("/:id/comments", handlers.CreateTaskComment)
+			tasks.GET("/:id/attachments", handlers.GetTaskAttachments)
+			tasks.POST("/:id/attachments", handlers.UploadTaskAttachment)
+			tasks.GET("/:id/attachments/:attachmentId", handlers.DownloadTaskAttachment)
+			tasks.DELETE("/:id/attachments/:attachmentId", handlers.DeleteTaskAttachment)
 		}
 
 		// 里程碑相关路由
 		milestones := protected.Group("/milestones")
 		{
 			milestones.GET("", handlers.GetAllMilestones)
+			milestones.GET("/export", handlers.ExportMilestones)
+			milestones.POST("/import", handlers.ImportMilestones)
 			milestones.GET("/:id", handlers.GetMilestoneByID)
 			milestones.POST("", handlers.CreateMilestone)
 			milestones.PUT("/:id", handlers.UpdateMilestone)
+			milestones.PATCH("/:id", handlers.PatchMilestone)
 			milestones.DELETE("/:id", handlers.DeleteMilestone)
+			milestones.GET("/:id/tasks", handlers.GetMilestoneTasks)
+			milestones.GET("/:id/activity", handlers.GetMilestoneActivity)
+			milestones.GET("/:id/comments", handlers.GetMilestoneComments)
+			milestones.POST("/:id/comments", handlers.CreateMilestoneComment)
+		}
+
+		// 评论相关路由
+		comments := protected.Group("/comments")
+		{
+			comments.PUT("/:id", handlers.UpdateComment)
+			comments.DELETE("/:id", handlers.DeleteComment)
 		}
 	}
-} 
\ No newline at end of file
+}
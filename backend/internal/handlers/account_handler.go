@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"project_management/internal/auth"
+	"project_management/internal/models"
+	"project_management/internal/repository"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 注销账号请求结构，需要再次输入密码确认
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// DeleteAccount 注销当前用户的账号。账号在回收站保留期内可以通过用户名和密码恢复，之后彻底删除
+func DeleteAccount(c *gin.Context) {
+	var req DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	user, err := repository.GetUserByID(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取用户信息失败"})
+		return
+	}
+
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+
+	if !user.CheckPassword(req.Password) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "密码错误", "code": "invalid_password"})
+		return
+	}
+
+	// 唯一所有者注销后项目将无人管理，需要先转让所有权
+	projectIDs, err := repository.GetSoleOwnedSharedProjectIDs(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目失败"})
+		return
+	}
+
+	if len(projectIDs) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":       "您是这些项目的唯一所有者，请先将所有权转让给其他成员",
+			"code":        "sole_project_owner",
+			"project_ids": projectIDs,
+		})
+		return
+	}
+
+	if err := repository.DeleteUser(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "注销账号失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "账号已注销", "purge_at": time.Now().Add(models.TrashRetention())})
+}
+
+// RestoreAccount 通过用户名和密码恢复已注销但尚未彻底删除的账号，恢复后直接登录
+func RestoreAccount(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	user, err := repository.GetDeletedUserByUsername(req.Username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "服务器错误"})
+		return
+	}
+
+	if user == nil || !user.CheckPassword(req.Password) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户名或密码错误"})
+		return
+	}
+
+	if err := repository.RestoreUser(user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "恢复账号失败"})
+		return
+	}
+
+	accessToken, refreshToken, err := auth.GenerateTokens(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成令牌失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		UserID:       user.ID,
+		Username:     user.Username,
+		Name:         user.Name,
+	})
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"project_management/internal/middleware"
+	"project_management/internal/models"
+	"project_management/internal/notification"
+	"project_management/internal/repository"
+	"project_management/internal/stream"
+	"project_management/internal/webhook"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetActivityFeed 获取当前用户参与的所有项目中的操作记录，可通过project_id限定项目
+func GetActivityFeed(c *gin.Context) {
+	filter := repository.ActivityFilter{}
+	if projectID := c.Query("project_id"); projectID != "" {
+		id, err := strconv.ParseUint(projectID, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+			return
+		}
+		if !middleware.CheckProjectPermission(c, uint(id), models.PermissionView) {
+			return
+		}
+		filter.ProjectIDs = []uint{uint(id)}
+	} else {
+		projectIDs, err := repository.GetUserProjectIDs(c.GetUint("userID"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取操作记录失败"})
+			return
+		}
+		filter.ProjectIDs = projectIDs
+	}
+
+	listActivities(c, filter)
+}
+
+// GetTaskActivity 获取任务的操作记录
+func GetTaskActivity(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+
+	task, err := repository.GetTaskByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务失败"})
+		return
+	}
+
+	if task == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+		return
+	}
+
+	if !middleware.CheckProjectPermission(c, task.ProjectID, models.PermissionView) {
+		return
+	}
+
+	listEntityActivities(c, models.EntityTask, task.ID)
+}
+
+// GetMilestoneActivity 获取里程碑的操作记录
+func GetMilestoneActivity(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的里程碑ID"})
+		return
+	}
+
+	milestone, err := repository.GetMilestoneByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取里程碑失败"})
+		return
+	}
+
+	if milestone == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "里程碑不存在"})
+		return
+	}
+
+	if !middleware.CheckProjectPermission(c, milestone.ProjectID, models.PermissionView) {
+		return
+	}
+
+	listEntityActivities(c, models.EntityMilestone, milestone.ID)
+}
+
+// listEntityActivities 查询单个实体的操作记录，实体在项目间移动过时只返回当前用户可见项目中的记录
+func listEntityActivities(c *gin.Context, entityType models.EntityType, entityID uint) {
+	projectIDs, err := repository.GetUserProjectIDs(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取操作记录失败"})
+		return
+	}
+
+	listActivities(c, repository.ActivityFilter{ProjectIDs: projectIDs, EntityType: entityType, EntityID: &entityID})
+}
+
+// recordActivity 记录当前用户对实体的操作，before为nil表示创建，after为nil表示删除。
+// 修改操作没有字段变化时不记录；记录失败只写入日志，不影响请求结果
+func recordActivity(c *gin.Context, projectID uint, entityType models.EntityType, entityID uint, before, after models.Snapshot) {
+	action := models.ActivityUpdate
+	switch {
+	case before == nil:
+		action = models.ActivityCreate
+	case after == nil:
+		action = models.ActivityDelete
+	}
+
+	changes := models.DiffSnapshots(before, after)
+	if action == models.ActivityUpdate && len(changes) == 0 {
+		return
+	}
+
+	data := after
+	if action == models.ActivityDelete {
+		data = before
+	}
+	saveActivity(c, projectID, entityType, entityID, action, changes, data)
+}
+
+// recordRestore 记录当前用户从回收站恢复实体的操作，变更内容为恢复后的全部字段
+func recordRestore(c *gin.Context, projectID uint, entityType models.EntityType, entityID uint, after models.Snapshot) {
+	saveActivity(c, projectID, entityType, entityID, models.ActivityRestore, models.DiffSnapshots(nil, after), after)
+}
+
+// saveActivity 以当前用户身份保存操作记录，推送给事件流的订阅者，向订阅了对应事件的Webhook发送通知，
+// 并为相关用户生成站内通知，data为Webhook通知中的实体字段。
+// 失败只写入日志
+func saveActivity(c *gin.Context, projectID uint, entityType models.EntityType, entityID uint, action models.ActivityAction, changes models.FieldChanges, data models.Snapshot) {
+	actorID := c.GetUint("userID")
+	activity := &models.Activity{
+		ProjectID:  projectID,
+		ActorID:    &actorID,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		Changes:    changes,
+	}
+	if err := repository.CreateActivity(activity); err != nil {
+		log.Printf("记录操作失败: %s %s #%d: %v", action, entityType, entityID, err)
+		return
+	}
+	stream.Current().Publish(*activity)
+	if err := webhook.Enqueue(activity, data); err != nil {
+		log.Printf("创建Webhook投递失败: %s %s #%d: %v", action, entityType, entityID, err)
+	}
+	if err := notification.FromActivity(activity, data); err != nil {
+		log.Printf("创建通知失败: %s %s #%d: %v", action, entityType, entityID, err)
+	}
+}
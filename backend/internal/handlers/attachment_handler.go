@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"project_management/internal/middleware"
+	"project_management/internal/models"
+	"project_management/internal/repository"
+	"project_management/internal/storage"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 附件上传限制的默认值，可通过环境变量ATTACHMENT_MAX_SIZE和ATTACHMENT_ALLOWED_TYPES覆盖
+const defaultAttachmentMaxSize = 10 << 20
+
+var defaultAttachmentTypes = []string{
+	"image/png", "image/jpeg", "image/gif", "image/webp",
+	"application/pdf", "application/zip", "application/json",
+	"text/plain", "text/csv", "text/markdown",
+	"application/msword", "application/vnd.ms-excel", "application/vnd.ms-powerpoint",
+	"application/vnd.openxmlformats-officedocument.*",
+}
+
+// GetTaskAttachments 获取任务的附件列表
+func GetTaskAttachments(c *gin.Context) {
+	task, ok := loadAttachmentTask(c, models.PermissionView)
+	if !ok {
+		return
+	}
+
+	attachments, err := repository.GetTaskAttachments(task.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取附件失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// UploadTaskAttachment 上传任务附件，文件通过multipart表单的file字段传入
+func UploadTaskAttachment(c *gin.Context) {
+	task, ok := loadAttachmentTask(c, models.PermissionEdit)
+	if !ok {
+		return
+	}
+
+	// 限制请求体大小，预留表单其他部分的空间
+	maxSize := attachmentMaxSize()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+1<<20)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeFileTooLarge(c, maxSize)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请选择要上传的文件"})
+		return
+	}
+	if header.Size > maxSize {
+		writeFileTooLarge(c, maxSize)
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "读取上传文件失败"})
+		return
+	}
+	defer file.Close()
+
+	// 根据文件内容和扩展名识别类型，不信任客户端声明的类型
+	fileName := sanitizeFileName(header.Filename)
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "读取上传文件失败"})
+		return
+	}
+	contentType := detectContentType(fileName, head[:n])
+	if !attachmentTypeAllowed(contentType) {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{
+			"error":        "不支持的文件类型: " + contentType,
+			"code":         "unsupported_type",
+			"content_type": contentType,
+		})
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取上传文件失败"})
+		return
+	}
+
+	key, err := newStorageKey(task.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "上传附件失败"})
+		return
+	}
+
+	hash := sha256.New()
+	if err := storage.Current().Put(c.Request.Context(), key, io.TeeReader(file, hash), header.Size, contentType); err != nil {
+		log.Printf("保存附件失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "上传附件失败"})
+		return
+	}
+
+	attachment := &models.Attachment{
+		TaskID:      task.ID,
+		UploaderID:  c.GetUint("userID"),
+		FileName:    fileName,
+		ContentType: contentType,
+		Size:        header.Size,
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+		StorageKey:  key,
+	}
+	if err := repository.CreateAttachment(attachment); err != nil {
+		removeStoredFiles([]string{key})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "上传附件失败"})
+		return
+	}
+
+	created, err := repository.GetAttachmentByID(attachment.ID)
+	if err != nil || created == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取附件失败"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// DownloadTaskAttachment 下载任务附件
+func DownloadTaskAttachment(c *gin.Context) {
+	attachment, ok := loadAttachment(c, models.PermissionView)
+	if !ok {
+		return
+	}
+
+	reader, err := storage.Current().Get(c.Request.Context(), attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "附件文件不存在"})
+			return
+		}
+		log.Printf("读取附件失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "读取附件失败"})
+		return
+	}
+	defer reader.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, reader, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// DeleteTaskAttachment 删除任务附件
+func DeleteTaskAttachment(c *gin.Context) {
+	attachment, ok := loadAttachment(c, models.PermissionEdit)
+	if !ok {
+		return
+	}
+
+	if err := repository.DeleteAttachment(attachment.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除附件失败"})
+		return
+	}
+	removeStoredFiles([]string{attachment.StorageKey})
+
+	c.JSON(http.StatusOK, gin.H{"message": "附件已删除"})
+}
+
+// loadAttachmentTask 根据路由参数加载任务并检查权限，失败时已写入响应
+func loadAttachmentTask(c *gin.Context, perm models.ProjectPermission) (*models.Task, bool) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return nil, false
+	}
+
+	task, err := repository.GetTaskByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务失败"})
+		return nil, false
+	}
+
+	if task == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+		return nil, false
+	}
+
+	if !middleware.CheckProjectPermission(c, task.ProjectID, perm) {
+		return nil, false
+	}
+
+	return task, true
+}
+
+// loadAttachment 根据路由参数加载属于该任务的附件并检查权限，失败时已写入响应
+func loadAttachment(c *gin.Context, perm models.ProjectPermission) (*models.Attachment, bool) {
+	task, ok := loadAttachmentTask(c, perm)
+	if !ok {
+		return nil, false
+	}
+
+	attachmentID, err := strconv.ParseUint(c.Param("attachmentId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的附件ID"})
+		return nil, false
+	}
+
+	attachment, err := repository.GetAttachmentByID(uint(attachmentID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取附件失败"})
+		return nil, false
+	}
+
+	if attachment == nil || attachment.TaskID != task.ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "附件不存在"})
+		return nil, false
+	}
+
+	return attachment, true
+}
+
+// removeStoredFiles 从存储后端删除文件，失败只写入日志，数据库记录已删除时调用
+func removeStoredFiles(keys []string) {
+	for _, key := range keys {
+		if err := storage.Current().Delete(context.Background(), key); err != nil {
+			log.Printf("删除附件文件失败: %s: %v", key, err)
+		}
+	}
+}
+
+// attachmentMaxSize 单个附件的最大字节数
+func attachmentMaxSize() int64 {
+	if size, err := strconv.ParseInt(os.Getenv("ATTACHMENT_MAX_SIZE"), 10, 64); err == nil && size > 0 {
+		return size
+	}
+	return defaultAttachmentMaxSize
+}
+
+// attachmentTypeAllowed 检查文件类型是否在允许列表中，列表项以 /* 或 .* 结尾时按前缀匹配
+func attachmentTypeAllowed(contentType string) bool {
+	allowed := defaultAttachmentTypes
+	if config := os.Getenv("ATTACHMENT_ALLOWED_TYPES"); config != "" {
+		allowed = strings.Split(config, ",")
+	}
+
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "*" || pattern == contentType {
+			return true
+		}
+		if strings.HasSuffix(pattern, "*") && strings.HasPrefix(contentType, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// detectContentType 根据文件内容识别类型，内容无法区分时（纯文本、zip容器等）参考扩展名
+func detectContentType(fileName string, head []byte) string {
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "application/octet-stream"
+	}
+
+	switch mediaType {
+	case "application/octet-stream", "text/plain", "application/zip":
+		if byExt := mime.TypeByExtension(strings.ToLower(filepath.Ext(fileName))); byExt != "" {
+			if extType, _, err := mime.ParseMediaType(byExt); err == nil {
+				return extType
+			}
+		}
+	}
+	return mediaType
+}
+
+// sanitizeFileName 去除文件名中的路径部分并限制长度
+func sanitizeFileName(name string) string {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "" || name == "." || name == "/" {
+		return "file"
+	}
+	for utf8.RuneCountInString(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+// newStorageKey 为任务附件生成随机的对象键
+func newStorageKey(taskID uint) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("tasks/%d/%s", taskID, hex.EncodeToString(b)), nil
+}
+
+// writeFileTooLarge 写入文件过大的错误响应
+func writeFileTooLarge(c *gin.Context, maxSize int64) {
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error":    fmt.Sprintf("文件大小不能超过 %d 字节", maxSize),
+		"code":     "file_too_large",
+		"max_size": maxSize,
+	})
+}
@@ -87,14 +87,14 @@ func Register(c *gin.Context) {
 		return
 	}
 
-	// 检查用户名是否已存在
-	existingUser, err := repository.GetUserByUsername(req.Username)
+	// 检查用户名是否已存在，已注销但尚未彻底删除的用户仍占用用户名
+	exists, err := repository.UsernameExists(req.Username)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "服务器错误"})
 		return
 	}
 
-	if existingUser != nil {
+	if exists {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "用户名已存在"})
 		return
 	}
@@ -196,17 +196,21 @@ func GetCurrentUser(c *gin.Context) {
 	userCount, err := repository.GetTotalUserCount()
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
-			"id":       user.ID,
-			"username": user.Username,
-			"name":     user.Name,
+			"id":             user.ID,
+			"username":       user.Username,
+			"name":           user.Name,
+			"email":          user.Email,
+			"email_verified": user.HasVerifiedEmail(),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"id":                user.ID,
-		"username":          user.Username,
-		"name":              user.Name,
+		"id":                 user.ID,
+		"username":           user.Username,
+		"name":               user.Name,
+		"email":              user.Email,
+		"email_verified":     user.HasVerifiedEmail(),
 		"organization_users": userCount,
 	})
 }
\ No newline at end of file
@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"project_management/internal/models"
+	"project_management/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 批量操作的动作
+const (
+	BulkActionSetStatus     = "set_status"
+	BulkActionSetUrgency    = "set_urgency"
+	BulkActionReassign      = "reassign"
+	BulkActionMoveMilestone = "move_milestone"
+	BulkActionDelete        = "delete"
+)
+
+// 单次批量操作最多包含的任务数
+const maxBulkTasks = 100
+
+// 批量操作请求结构，根据action使用对应的参数
+type BulkTaskRequest struct {
+	TaskIDs     []uint             `json:"task_ids" binding:"required"`
+	Action      string             `json:"action" binding:"required"`
+	Status      models.TaskStatus  `json:"status"`       // set_status
+	Urgency     models.TaskUrgency `json:"urgency"`      // set_urgency
+	AssigneeIDs []uint             `json:"assignee_ids"` // reassign，为空表示清空负责人
+	MilestoneID *uint              `json:"milestone_id"` // move_milestone，为null表示移出里程碑
+	Cascade     bool               `json:"cascade"`      // delete，一并删除全部下级任务
+	Atomic      bool               `json:"atomic"`       // 任一任务失败时不修改任何任务
+}
+
+// BulkTaskResult 批量操作中单个任务的结果
+type BulkTaskResult struct {
+	TaskID  uint                   `json:"task_id"`
+	Success bool                   `json:"success"`
+	Status  int                    `json:"status"`            // 与单独操作该任务时一致的HTTP状态码
+	Task    *models.Task           `json:"task,omitempty"`    // 修改后的任务，删除时为空
+	Error   string                 `json:"error,omitempty"`   // 失败原因
+	Code    string                 `json:"code,omitempty"`    // 错误代码
+	Details map[string]interface{} `json:"details,omitempty"` // 错误的其他信息，如阻塞任务、允许的状态
+}
+
+// bulkItem 批量操作中通过校验、等待保存的任务
+type bulkItem struct {
+	result   *BulkTaskResult
+	task     *models.Task
+	before   models.Snapshot
+	affected []models.Task // 删除时受影响的下级任务
+}
+
+// BulkTasks 对多个任务执行同一操作：修改状态、修改紧急程度、重新分配负责人、移动到里程碑或移入回收站。
+// 每个任务使用与单独修改时相同的校验规则，全部修改在同一事务中执行，响应中包含每个任务的结果。
+// atomic为true时任一任务失败都不会修改任何任务，并返回422
+func BulkTasks(c *gin.Context) {
+	var req BulkTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	switch req.Action {
+	case BulkActionSetStatus:
+		if req.Status == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "请指定任务状态"})
+			return
+		}
+	case BulkActionSetUrgency:
+		if req.Urgency == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "请指定紧急程度"})
+			return
+		}
+	case BulkActionReassign, BulkActionMoveMilestone, BulkActionDelete:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的批量操作"})
+		return
+	}
+
+	// 去除重复的任务ID
+	seen := make(map[uint]bool, len(req.TaskIDs))
+	taskIDs := make([]uint, 0, len(req.TaskIDs))
+	for _, id := range req.TaskIDs {
+		if !seen[id] {
+			seen[id] = true
+			taskIDs = append(taskIDs, id)
+		}
+	}
+	if len(taskIDs) == 0 || len(taskIDs) > maxBulkTasks {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("任务数量必须在1到%d之间", maxBulkTasks)})
+		return
+	}
+
+	// 逐个校验，校验函数写入的响应记录为该任务的结果
+	results := make([]BulkTaskResult, len(taskIDs))
+	var items []*bulkItem
+	for i, id := range taskIDs {
+		results[i] = BulkTaskResult{TaskID: id}
+		item := &bulkItem{result: &results[i]}
+		captureBulkResult(c, item.result, func() bool {
+			return prepareBulkItem(c, &req, id, item)
+		})
+		if item.result.Success {
+			items = append(items, item)
+		}
+	}
+
+	// 级联删除时，已被其他任务一并删除的下级任务不再单独删除
+	if req.Action == BulkActionDelete && req.Cascade {
+		covered := make(map[uint]bool)
+		for _, item := range items {
+			for _, task := range item.affected {
+				covered[task.ID] = true
+			}
+		}
+		kept := items[:0]
+		for _, item := range items {
+			if !covered[item.task.ID] {
+				kept = append(kept, item)
+			}
+		}
+		items = kept
+	}
+
+	failed := len(taskIDs) - countSucceeded(results)
+	if req.Atomic && failed > 0 {
+		writeBulkRollback(c, &req, results)
+		return
+	}
+
+	changes := make([]repository.BulkTaskChange, len(items))
+	for i, item := range items {
+		changes[i] = repository.BulkTaskChange{Task: item.task, Delete: req.Action == BulkActionDelete, Cascade: req.Cascade}
+	}
+	errs, err := repository.ApplyBulkTaskChanges(changes, req.Atomic)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "批量操作失败"})
+		return
+	}
+
+	for i, item := range items {
+		if errs[i] != nil {
+			captureBulkResult(c, item.result, func() bool {
+				writeTaskSaveError(c, item.task.ID, errs[i])
+				return false
+			})
+		}
+	}
+	if req.Atomic && countSucceeded(results) < len(results) {
+		writeBulkRollback(c, &req, results)
+		return
+	}
+
+	// 记录操作记录并返回修改后的任务
+	for i, item := range items {
+		if errs[i] != nil {
+			continue
+		}
+		if req.Action == BulkActionDelete {
+			recordTaskDeletion(c, item.task, item.affected, req.Cascade)
+			continue
+		}
+		recordActivity(c, item.task.ProjectID, models.EntityTask, item.task.ID, item.before, models.TaskSnapshot(item.task))
+		item.result.Task = item.task
+	}
+
+	succeeded := countSucceeded(results)
+	c.JSON(http.StatusOK, gin.H{
+		"action":    req.Action,
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+		"results":   results,
+	})
+}
+
+// prepareBulkItem 获取任务、检查权限并按操作校验和修改任务，失败时已写入响应
+func prepareBulkItem(c *gin.Context, req *BulkTaskRequest, id uint, item *bulkItem) bool {
+	task, ok := loadEditableTask(c, id)
+	if !ok {
+		return false
+	}
+	item.task = task
+	item.before = models.TaskSnapshot(task)
+
+	if req.Action == BulkActionDelete {
+		affected, err := taskDeletionAffected(task, req.Cascade)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取子任务失败"})
+			return false
+		}
+		item.affected = affected
+		return true
+	}
+
+	update := taskRequestFrom(task)
+	switch req.Action {
+	case BulkActionSetStatus:
+		update.Status = req.Status
+	case BulkActionSetUrgency:
+		update.Urgency = req.Urgency
+	case BulkActionReassign:
+		update.AssigneeIDs = req.AssigneeIDs
+	case BulkActionMoveMilestone:
+		update.MilestoneID = req.MilestoneID
+	}
+	return prepareTaskUpdate(c, task, &update)
+}
+
+// writeBulkRollback 在全部成功模式下有任务失败时返回422，本可成功的任务标记为已回滚
+func writeBulkRollback(c *gin.Context, req *BulkTaskRequest, results []BulkTaskResult) {
+	failed := 0
+	for i := range results {
+		if results[i].Success {
+			results[i] = BulkTaskResult{
+				TaskID: results[i].TaskID,
+				Status: http.StatusFailedDependency,
+				Error:  "其他任务操作失败，已全部回滚",
+				Code:   "bulk_rolled_back",
+			}
+		} else {
+			failed++
+		}
+	}
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"error":     "部分任务操作失败，未修改任何任务",
+		"code":      "bulk_failed",
+		"action":    req.Action,
+		"succeeded": 0,
+		"failed":    failed,
+		"results":   results,
+	})
+}
+
+// countSucceeded 统计成功的任务数
+func countSucceeded(results []BulkTaskResult) int {
+	count := 0
+	for _, result := range results {
+		if result.Success {
+			count++
+		}
+	}
+	return count
+}
+
+// captureBulkResult 执行会写入响应的校验函数，并将其结果记录到result中
+func captureBulkResult(c *gin.Context, result *BulkTaskResult, fn func() bool) {
+	failure := captureResponse(c, fn)
+	if failure == nil {
+		result.Success = true
+		result.Status = http.StatusOK
+		return
+	}
+	result.Success = false
+	result.Status = failure.Status
+	result.Error = failure.Error
+	result.Code = failure.Code
+	result.Details = failure.Details
+}
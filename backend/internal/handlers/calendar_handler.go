@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"project_management/internal/ical"
+	"project_management/internal/middleware"
+	"project_management/internal/models"
+	"project_management/internal/repository"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 日历订阅只包含最近这些天以来及以后的任务和里程碑
+const calendarLookbackDays = 90
+
+// calendarUIDDomain 日历条目UID的域名部分，UID由实体类型和ID组成，保证同一条目在刷新后保持不变
+const calendarUIDDomain = "project-management"
+
+// 任务在日历中的呈现方式
+const (
+	calendarTasksAsTodo  = "todo"
+	calendarTasksAsEvent = "event"
+)
+
+// taskPriorities 紧急程度对应的iCalendar优先级，1为最高
+var taskPriorities = map[models.TaskUrgency]int{
+	models.TaskUrgencyUrgent: 1,
+	models.TaskUrgencyHigh:   3,
+	models.TaskUrgencyMedium: 5,
+	models.TaskUrgencyLow:    9,
+}
+
+// todoStatuses 任务状态对应的VTODO状态
+var todoStatuses = map[models.TaskStatus]string{
+	models.TaskStatusPending:   "NEEDS-ACTION",
+	models.TaskStatusInProcess: "IN-PROCESS",
+	models.TaskStatusCompleted: "COMPLETED",
+	models.TaskStatusDelayed:   "NEEDS-ACTION",
+}
+
+// GetCalendarSubscription 获取当前用户的日历订阅地址，未开启订阅时enabled为false
+func GetCalendarSubscription(c *gin.Context) {
+	user, err := repository.GetUserByID(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取用户信息失败"})
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+
+	if user.CalendarToken == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"enabled": true, "url": calendarURL(c, *user.CalendarToken)})
+}
+
+// ResetCalendarSubscription 开启日历订阅或重新生成订阅地址，旧地址立即失效
+func ResetCalendarSubscription(c *gin.Context) {
+	buf := make([]byte, 20)
+	if _, err := rand.Read(buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成订阅地址失败"})
+		return
+	}
+	token := hex.EncodeToString(buf)
+
+	if err := repository.SetUserCalendarToken(c.GetUint("userID"), &token); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成订阅地址失败"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"enabled": true, "url": calendarURL(c, token)})
+}
+
+// DeleteCalendarSubscription 关闭日历订阅，订阅地址立即失效
+func DeleteCalendarSubscription(c *gin.Context) {
+	if err := repository.SetUserCalendarToken(c.GetUint("userID"), nil); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "关闭日历订阅失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "日历订阅已关闭"})
+}
+
+// GetCalendarFeed 输出订阅令牌所属用户的iCalendar订阅，包含里程碑（VEVENT）和任务（默认为VTODO）。
+// 查询参数：project_id（逗号分隔或重复传入，默认为用户参与的全部项目）、
+// assignee_id（用户ID、me或all，默认为me，即只包含分配给自己的任务）、tasks（todo或event，任务的呈现方式）
+func GetCalendarFeed(c *gin.Context) {
+	token, ok := strings.CutSuffix(c.Param("token"), ".ics")
+	if !ok || token == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "订阅地址无效"})
+		return
+	}
+
+	user, err := repository.GetUserByCalendarToken(token)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取日历失败"})
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "订阅地址无效"})
+		return
+	}
+	// 以令牌所属用户的身份检查项目权限
+	c.Set("userID", user.ID)
+
+	projectIDs, ok := calendarProjectIDs(c)
+	if !ok {
+		return
+	}
+
+	assigneeID := &user.ID
+	switch assignee := c.DefaultQuery("assignee_id", "me"); assignee {
+	case "me":
+	case "all":
+		assigneeID = nil
+	default:
+		id, err := strconv.ParseUint(assignee, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的负责人ID"})
+			return
+		}
+		userID := uint(id)
+		assigneeID = &userID
+	}
+
+	tasksAs := c.DefaultQuery("tasks", calendarTasksAsTodo)
+	if tasksAs != calendarTasksAsTodo && tasksAs != calendarTasksAsEvent {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tasks参数只能为todo或event"})
+		return
+	}
+
+	since := models.OverdueCutoff().AddDate(0, 0, -calendarLookbackDays)
+	tasks, err := repository.GetCalendarTasks(projectIDs, assigneeID, since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务失败"})
+		return
+	}
+	milestones, err := repository.GetCalendarMilestones(projectIDs, since)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取里程碑失败"})
+		return
+	}
+	projects, err := repository.GetProjectsByIDs(projectIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目失败"})
+		return
+	}
+	projectNames := make(map[uint]string, len(projects))
+	for _, project := range projects {
+		projectNames[project.ID] = project.Name
+	}
+
+	var b ical.Builder
+	b.Begin("VCALENDAR")
+	b.Raw("VERSION", "2.0")
+	b.Raw("PRODID", "-//project_management//calendar//ZH")
+	b.Raw("CALSCALE", "GREGORIAN")
+	b.Raw("METHOD", "PUBLISH")
+	b.Text("X-WR-CALNAME", "项目管理 - "+user.Name)
+	b.Raw("REFRESH-INTERVAL;VALUE=DURATION", "PT1H")
+	b.Raw("X-PUBLISHED-TTL", "PT1H")
+	for i := range milestones {
+		writeMilestoneEvent(&b, &milestones[i], projectNames[milestones[i].ProjectID])
+	}
+	for i := range tasks {
+		writeTaskEntry(&b, &tasks[i], projectNames[tasks[i].ProjectID], tasksAs)
+	}
+	b.End("VCALENDAR")
+
+	c.Header("Content-Disposition", `inline; filename="calendar.ics"`)
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", b.Bytes())
+}
+
+// calendarProjectIDs 读取订阅包含的项目，失败时已写入响应
+func calendarProjectIDs(c *gin.Context) ([]uint, bool) {
+	values := queryList(c, "project_id")
+	if len(values) == 0 {
+		projectIDs, err := repository.GetUserProjectIDs(c.GetUint("userID"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取日历失败"})
+			return nil, false
+		}
+		return projectIDs, true
+	}
+
+	projectIDs := make([]uint, 0, len(values))
+	for _, value := range values {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+			return nil, false
+		}
+		if !middleware.CheckProjectPermission(c, uint(id), models.PermissionView) {
+			return nil, false
+		}
+		projectIDs = append(projectIDs, uint(id))
+	}
+	return projectIDs, true
+}
+
+// writeMilestoneEvent 将里程碑写为全天的VEVENT
+func writeMilestoneEvent(b *ical.Builder, milestone *models.Milestone, projectName string) {
+	b.Begin("VEVENT")
+	b.Raw("UID", fmt.Sprintf("milestone-%d@%s", milestone.ID, calendarUIDDomain))
+	b.Time("DTSTAMP", milestone.UpdatedAt)
+	b.Time("LAST-MODIFIED", milestone.UpdatedAt)
+	b.Raw("SEQUENCE", strconv.FormatUint(uint64(calendarSequence(milestone.Version)), 10))
+	b.Date("DTSTART", milestone.Date)
+	b.Date("DTEND", milestone.Date.AddDate(0, 0, 1))
+	b.Text("SUMMARY", "里程碑："+milestone.Title)
+	description := "项目：" + projectName
+	if milestone.Description != "" {
+		description += "\n" + milestone.Description
+	}
+	b.Text("DESCRIPTION", description)
+	b.Text("CATEGORIES", projectName)
+	b.Raw("TRANSP", "TRANSPARENT")
+	b.End("VEVENT")
+}
+
+// writeTaskEntry 将任务写为以截止日期为期限的VTODO，tasksAs为event时写为截止日期当天的全天VEVENT
+func writeTaskEntry(b *ical.Builder, task *models.Task, projectName, tasksAs string) {
+	component := "VTODO"
+	if tasksAs == calendarTasksAsEvent {
+		component = "VEVENT"
+	}
+
+	b.Begin(component)
+	b.Raw("UID", fmt.Sprintf("task-%d@%s", task.ID, calendarUIDDomain))
+	b.Time("DTSTAMP", task.UpdatedAt)
+	b.Time("LAST-MODIFIED", task.UpdatedAt)
+	b.Raw("SEQUENCE", strconv.FormatUint(uint64(calendarSequence(task.Version)), 10))
+
+	summary := task.Name
+	if component == "VTODO" {
+		// RFC 5545要求DUE晚于DTSTART，开始日期与截止日期为同一天时只写DUE
+		if task.StartDate != nil && task.StartDate.Format("20060102") < task.Deadline.Format("20060102") {
+			b.Date("DTSTART", *task.StartDate)
+		}
+		b.Date("DUE", task.Deadline)
+		b.Raw("STATUS", todoStatuses[task.Status])
+		if task.CompletedAt != nil {
+			b.Time("COMPLETED", *task.CompletedAt)
+		}
+	} else {
+		b.Date("DTSTART", task.Deadline)
+		b.Date("DTEND", task.Deadline.AddDate(0, 0, 1))
+		b.Raw("TRANSP", "TRANSPARENT")
+		if task.Status == models.TaskStatusCompleted {
+			summary = "[已完成] " + summary
+		}
+	}
+	b.Text("SUMMARY", summary)
+	if priority, ok := taskPriorities[task.Urgency]; ok {
+		b.Raw("PRIORITY", strconv.Itoa(priority))
+	}
+
+	names := make([]string, len(task.Assignees))
+	for i, user := range task.Assignees {
+		names[i] = user.Name
+	}
+	description := fmt.Sprintf("项目：%s\n状态：%s\n紧急程度：%s", projectName, task.Status, task.Urgency)
+	if len(names) > 0 {
+		description += "\n负责人：" + strings.Join(names, "、")
+	}
+	b.Text("DESCRIPTION", description)
+	b.Text("CATEGORIES", projectName)
+	b.End(component)
+}
+
+// calendarSequence 根据版本号计算条目的修订序号，新建的条目为0
+func calendarSequence(version uint) uint {
+	if version == 0 {
+		return 0
+	}
+	return version - 1
+}
+
+// calendarURL 根据当前请求的地址生成订阅地址
+func calendarURL(c *gin.Context, token string) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return fmt.Sprintf("%s://%s/api/calendar/%s.ics", scheme, c.Request.Host, token)
+}
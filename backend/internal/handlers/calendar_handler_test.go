@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"project_management/internal/ical"
+	"project_management/internal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteTaskEntryDates(t *testing.T) {
+	date := func(day int) *time.Time {
+		d := time.Date(2025, 3, day, 0, 0, 0, 0, time.Local)
+		return &d
+	}
+
+	tests := []struct {
+		name      string
+		startDate *time.Time
+		tasksAs   string
+		want      []string
+		notWant   []string
+	}{
+		{
+			name:      "开始日期早于截止日期",
+			startDate: date(1),
+			tasksAs:   calendarTasksAsTodo,
+			want:      []string{"DTSTART;VALUE=DATE:20250301", "DUE;VALUE=DATE:20250305"},
+		},
+		{
+			name:      "开始日期与截止日期相同时不写DTSTART",
+			startDate: date(5),
+			tasksAs:   calendarTasksAsTodo,
+			want:      []string{"DUE;VALUE=DATE:20250305"},
+			notWant:   []string{"DTSTART"},
+		},
+		{
+			name:    "没有开始日期",
+			tasksAs: calendarTasksAsTodo,
+			want:    []string{"DUE;VALUE=DATE:20250305"},
+			notWant: []string{"DTSTART"},
+		},
+		{
+			name:      "写为全天事件",
+			startDate: date(5),
+			tasksAs:   calendarTasksAsEvent,
+			want:      []string{"DTSTART;VALUE=DATE:20250305", "DTEND;VALUE=DATE:20250306"},
+			notWant:   []string{"DUE"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &models.Task{
+				ID:        1,
+				Name:      "发布",
+				StartDate: tt.startDate,
+				Deadline:  *date(5),
+				Status:    models.TaskStatusPending,
+				Urgency:   models.TaskUrgencyMedium,
+			}
+			var b ical.Builder
+			writeTaskEntry(&b, task, "项目", tt.tasksAs)
+			lines := strings.Split(string(b.Bytes()), "\r\n")
+
+			has := func(prefix string) bool {
+				for _, line := range lines {
+					if strings.HasPrefix(line, prefix) {
+						return true
+					}
+				}
+				return false
+			}
+			for _, line := range tt.want {
+				if !has(line) {
+					t.Errorf("missing %s in %q", line, lines)
+				}
+			}
+			for _, prefix := range tt.notWant {
+				if has(prefix) {
+					t.Errorf("unexpected %s in %q", prefix, lines)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"project_management/internal/middleware"
+	"project_management/internal/models"
+	"project_management/internal/notification"
+	"project_management/internal/repository"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxCommentLength 评论内容的最大字符数
+const maxCommentLength = 5000
+
+// 评论请求结构，parent_id不为空时表示回复该评论
+type CommentRequest struct {
+	Content  string `json:"content" binding:"required"`
+	ParentID *uint  `json:"parent_id"`
+}
+
+// 修改评论请求结构
+type CommentUpdateRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// GetTaskComments 获取任务的评论
+func GetTaskComments(c *gin.Context) {
+	projectID, entityID, ok := loadCommentEntity(c, models.EntityTask)
+	if !ok {
+		return
+	}
+	if !middleware.CheckProjectPermission(c, projectID, models.PermissionView) {
+		return
+	}
+
+	listComments(c, models.EntityTask, entityID)
+}
+
+// CreateTaskComment 在任务下发表评论
+func CreateTaskComment(c *gin.Context) {
+	projectID, entityID, ok := loadCommentEntity(c, models.EntityTask)
+	if !ok {
+		return
+	}
+
+	createComment(c, projectID, models.EntityTask, entityID)
+}
+
+// GetMilestoneComments 获取里程碑的评论
+func GetMilestoneComments(c *gin.Context) {
+	projectID, entityID, ok := loadCommentEntity(c, models.EntityMilestone)
+	if !ok {
+		return
+	}
+	if !middleware.CheckProjectPermission(c, projectID, models.PermissionView) {
+		return
+	}
+
+	listComments(c, models.EntityMilestone, entityID)
+}
+
+// CreateMilestoneComment 在里程碑下发表评论
+func CreateMilestoneComment(c *gin.Context) {
+	projectID, entityID, ok := loadCommentEntity(c, models.EntityMilestone)
+	if !ok {
+		return
+	}
+
+	createComment(c, projectID, models.EntityMilestone, entityID)
+}
+
+// UpdateComment 修改评论，只有作者可以修改
+func UpdateComment(c *gin.Context) {
+	comment, projectID, ok := loadOwnComment(c)
+	if !ok {
+		return
+	}
+
+	var req CommentUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	content, ok := checkCommentContent(c, req.Content)
+	if !ok {
+		return
+	}
+
+	mentions, err := repository.GetProjectMemberUsersByUsernames(projectID, models.ParseMentions(content))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取提及的用户失败"})
+		return
+	}
+
+	// 只通知修改后新增的提及
+	var added []models.User
+	for _, user := range mentions {
+		if !slices.ContainsFunc(comment.Mentions, func(u models.User) bool { return u.ID == user.ID }) {
+			added = append(added, user)
+		}
+	}
+
+	now := time.Now()
+	comment.Content = content
+	comment.Mentions = mentions
+	comment.EditedAt = &now
+
+	if err := repository.UpdateComment(comment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "修改评论失败"})
+		return
+	}
+	if err := notification.Mentioned(projectID, comment, added); err != nil {
+		log.Printf("创建提及通知失败: comment #%d: %v", comment.ID, err)
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
+
+// DeleteComment 删除评论，只有作者可以删除，仍有回复的评论保留为占位
+func DeleteComment(c *gin.Context) {
+	comment, _, ok := loadOwnComment(c)
+	if !ok {
+		return
+	}
+
+	if err := repository.DeleteComment(comment.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除评论失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "评论已删除"})
+}
+
+// listComments 分页查询实体下的评论串并写入响应
+func listComments(c *gin.Context, entityType models.EntityType, entityID uint) {
+	opts, ok := parseListOptions(c)
+	if !ok {
+		return
+	}
+
+	comments, total, err := repository.ListComments(entityType, entityID, opts)
+	if err != nil {
+		writeListError(c, err, "获取评论失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, ListResponse{Items: comments, Total: total, Page: opts.Page, PageSize: opts.PageSize})
+}
+
+// createComment 在实体下创建评论或回复，记录并通知@提及的项目成员
+func createComment(c *gin.Context, projectID uint, entityType models.EntityType, entityID uint) {
+	if !middleware.CheckProjectPermission(c, projectID, models.PermissionComment) {
+		return
+	}
+
+	var req CommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	content, ok := checkCommentContent(c, req.Content)
+	if !ok {
+		return
+	}
+
+	// 回复的评论必须属于同一实体且未被删除
+	if req.ParentID != nil {
+		parent, err := repository.GetCommentByID(*req.ParentID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取评论失败"})
+			return
+		}
+		if parent == nil || parent.Deleted || parent.EntityType != entityType || parent.EntityID != entityID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "回复的评论不存在"})
+			return
+		}
+	}
+
+	mentions, err := repository.GetProjectMemberUsersByUsernames(projectID, models.ParseMentions(content))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取提及的用户失败"})
+		return
+	}
+
+	comment := &models.Comment{
+		EntityType: entityType,
+		EntityID:   entityID,
+		ParentID:   req.ParentID,
+		AuthorID:   c.GetUint("userID"),
+		Content:    content,
+		Mentions:   mentions,
+	}
+
+	if err := repository.CreateComment(comment); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "发表评论失败"})
+		return
+	}
+	if err := notification.Mentioned(projectID, comment, mentions); err != nil {
+		log.Printf("创建提及通知失败: comment #%d: %v", comment.ID, err)
+	}
+
+	created, err := repository.GetCommentByID(comment.ID)
+	if err != nil || created == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取评论失败"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// loadCommentEntity 根据路由参数加载评论所属的任务或里程碑，返回其项目ID和实体ID，失败时已写入响应
+func loadCommentEntity(c *gin.Context, entityType models.EntityType) (uint, uint, bool) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		if entityType == models.EntityTask {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的里程碑ID"})
+		}
+		return 0, 0, false
+	}
+
+	projectID, found, err := commentEntityProjectID(entityType, uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取评论对象失败"})
+		return 0, 0, false
+	}
+	if !found {
+		if entityType == models.EntityTask {
+			c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+		} else {
+			c.JSON(http.StatusNotFound, gin.H{"error": "里程碑不存在"})
+		}
+		return 0, 0, false
+	}
+
+	return projectID, uint(id), true
+}
+
+// commentEntityProjectID 获取评论所属任务或里程碑的项目ID
+func commentEntityProjectID(entityType models.EntityType, entityID uint) (uint, bool, error) {
+	switch entityType {
+	case models.EntityTask:
+		task, err := repository.GetTaskByID(entityID)
+		if err != nil || task == nil {
+			return 0, false, err
+		}
+		return task.ProjectID, true, nil
+	case models.EntityMilestone:
+		milestone, err := repository.GetMilestoneByID(entityID)
+		if err != nil || milestone == nil {
+			return 0, false, err
+		}
+		return milestone.ProjectID, true, nil
+	}
+	return 0, false, nil
+}
+
+// loadOwnComment 根据路由参数加载当前用户发表的评论，并检查其仍有评论权限，
+// 返回评论及其所属项目ID，失败时已写入响应
+func loadOwnComment(c *gin.Context) (*models.Comment, uint, bool) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的评论ID"})
+		return nil, 0, false
+	}
+
+	comment, err := repository.GetCommentByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取评论失败"})
+		return nil, 0, false
+	}
+
+	if comment == nil || comment.Deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "评论不存在"})
+		return nil, 0, false
+	}
+
+	projectID, found, err := commentEntityProjectID(comment.EntityType, comment.EntityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取评论对象失败"})
+		return nil, 0, false
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "评论不存在"})
+		return nil, 0, false
+	}
+
+	if !middleware.CheckProjectPermission(c, projectID, models.PermissionComment) {
+		return nil, 0, false
+	}
+
+	if comment.AuthorID != c.GetUint("userID") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只能修改或删除自己的评论", "code": "not_comment_author"})
+		return nil, 0, false
+	}
+
+	return comment, projectID, true
+}
+
+// checkCommentContent 去除评论内容首尾空白并检查长度，失败时已写入响应
+func checkCommentContent(c *gin.Context, content string) (string, bool) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "评论内容不能为空"})
+		return "", false
+	}
+	if utf8.RuneCountInString(content) > maxCommentLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "评论内容过长"})
+		return "", false
+	}
+	return content, true
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"net/http"
+	"project_management/internal/middleware"
+	"project_management/internal/models"
+	"project_management/internal/repository"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 任务依赖请求结构，blocker_id对应的任务阻塞当前任务
+type TaskDependencyRequest struct {
+	BlockerID uint `json:"blocker_id" binding:"required"`
+}
+
+// GetTaskDependencies 获取任务的依赖关系，包括阻塞它的任务和被它阻塞的任务
+func GetTaskDependencies(c *gin.Context) {
+	task, ok := loadDependencyTask(c, models.PermissionView)
+	if !ok {
+		return
+	}
+
+	blockedBy, err := repository.GetTaskBlockers(task.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务依赖失败"})
+		return
+	}
+
+	blocks, err := repository.GetTaskBlocking(task.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务依赖失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"blocked_by": blockedBy, "blocks": blocks})
+}
+
+// AddTaskDependency 添加阻塞当前任务的任务，会形成循环依赖时拒绝
+func AddTaskDependency(c *gin.Context) {
+	task, ok := loadDependencyTask(c, models.PermissionEdit)
+	if !ok {
+		return
+	}
+
+	var req TaskDependencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	blocker, err := repository.GetTaskByID(req.BlockerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务失败"})
+		return
+	}
+
+	if blocker == nil || blocker.ProjectID != task.ProjectID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "依赖的任务不存在或不属于该项目"})
+		return
+	}
+
+	existing, err := repository.GetTaskDependency(blocker.ID, task.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务依赖失败"})
+		return
+	}
+
+	if existing != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "依赖关系已存在", "code": "dependency_exists"})
+		return
+	}
+
+	// 如果当前任务已经直接或间接阻塞了blocker，新增的依赖会形成环
+	path, err := repository.FindDependencyPath(task.ID, blocker.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "检查循环依赖失败"})
+		return
+	}
+
+	if path != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "添加该依赖会形成循环依赖",
+			"code":  "dependency_cycle",
+			"cycle": append(path, task.ID),
+		})
+		return
+	}
+
+	before, err := dependencySnapshot(task.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务依赖失败"})
+		return
+	}
+
+	dependency := &models.TaskDependency{BlockerID: blocker.ID, BlockedID: task.ID}
+	if err := repository.CreateTaskDependency(dependency); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "添加任务依赖失败"})
+		return
+	}
+
+	if after, err := dependencySnapshot(task.ID); err == nil {
+		recordActivity(c, task.ProjectID, models.EntityTask, task.ID, before, after)
+	}
+
+	c.JSON(http.StatusCreated, dependency)
+}
+
+// RemoveTaskDependency 移除阻塞当前任务的任务
+func RemoveTaskDependency(c *gin.Context) {
+	task, ok := loadDependencyTask(c, models.PermissionEdit)
+	if !ok {
+		return
+	}
+
+	blockerID, err := strconv.ParseUint(c.Param("blockerId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+
+	existing, err := repository.GetTaskDependency(uint(blockerID), task.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务依赖失败"})
+		return
+	}
+
+	if existing == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "依赖关系不存在"})
+		return
+	}
+
+	before, err := dependencySnapshot(task.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务依赖失败"})
+		return
+	}
+
+	if err := repository.DeleteTaskDependency(uint(blockerID), task.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "移除任务依赖失败"})
+		return
+	}
+
+	if after, err := dependencySnapshot(task.ID); err == nil {
+		recordActivity(c, task.ProjectID, models.EntityTask, task.ID, before, after)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "依赖关系已移除"})
+}
+
+// checkTaskNotBlocked 检查任务进入进行中或已完成时是否还有未完成的阻塞任务，force为true时跳过检查，失败时已写入响应
+func checkTaskNotBlocked(c *gin.Context, task *models.Task, to models.TaskStatus, force bool) bool {
+	if force || to == task.Status || (to != models.TaskStatusInProcess && to != models.TaskStatusCompleted) {
+		return true
+	}
+
+	blockers, err := repository.GetOpenBlockers(task.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务依赖失败"})
+		return false
+	}
+
+	if len(blockers) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":    "任务被未完成的任务阻塞，可使用force=true强制变更",
+			"code":     "task_blocked",
+			"blockers": blockers,
+		})
+		return false
+	}
+
+	return true
+}
+
+// loadDependencyTask 根据路由参数加载任务并检查权限，失败时已写入响应
+func loadDependencyTask(c *gin.Context, perm models.ProjectPermission) (*models.Task, bool) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return nil, false
+	}
+
+	task, err := repository.GetTaskByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务失败"})
+		return nil, false
+	}
+
+	if task == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+		return nil, false
+	}
+
+	if !middleware.CheckProjectPermission(c, task.ProjectID, perm) {
+		return nil, false
+	}
+
+	return task, true
+}
+
+// dependencySnapshot 生成任务阻塞关系的快照，用于记录操作
+func dependencySnapshot(taskID uint) (models.Snapshot, error) {
+	blockers, err := repository.GetTaskBlockers(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, len(blockers))
+	for i, blocker := range blockers {
+		ids[i] = blocker.ID
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return models.Snapshot{"blocker_ids": ids}, nil
+}
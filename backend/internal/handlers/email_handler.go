@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/mail"
+	"project_management/internal/models"
+	"project_management/internal/notification"
+	"project_management/internal/repository"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 重新发送验证邮件的最短间隔
+const emailVerificationCooldown = time.Minute
+
+// 邮箱请求结构
+type EmailRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// GetEmail 获取当前用户的邮箱和验证状态
+func GetEmail(c *gin.Context) {
+	user, ok := loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, emailStatus(user))
+}
+
+// UpdateEmail 设置或修改当前用户的邮箱并发送验证邮件，验证前不会收到通知邮件
+func UpdateEmail(c *gin.Context) {
+	user, ok := loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var req EmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	email := strings.TrimSpace(req.Email)
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email || len(email) > 255 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的邮箱地址"})
+		return
+	}
+
+	if user.Email != nil && strings.EqualFold(*user.Email, email) && user.EmailVerifiedAt != nil {
+		c.JSON(http.StatusOK, emailStatus(user))
+		return
+	}
+
+	exists, err := repository.EmailExists(email, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "服务器错误"})
+		return
+	}
+	if exists {
+		c.JSON(http.StatusConflict, gin.H{"error": "邮箱已被其他用户使用", "code": "email_taken"})
+		return
+	}
+
+	verification, ok := newEmailVerification(c, user.ID, email)
+	if !ok {
+		return
+	}
+	if err := repository.SetUserEmail(user, &email, verification); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "修改邮箱失败"})
+		return
+	}
+	user.Email = &email
+	user.EmailVerifiedAt = nil
+
+	if err := notification.SendVerificationEmail(user, verification); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "发送验证邮件失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, emailStatus(user))
+}
+
+// DeleteEmail 删除当前用户的邮箱，之后不再收到通知邮件
+func DeleteEmail(c *gin.Context) {
+	user, ok := loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	if err := repository.SetUserEmail(user, nil, nil); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除邮箱失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "邮箱已删除"})
+}
+
+// ResendEmailVerification 重新发送验证邮件，之前的验证链接失效
+func ResendEmailVerification(c *gin.Context) {
+	user, ok := loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	if user.Email == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "尚未设置邮箱"})
+		return
+	}
+	if user.EmailVerifiedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "邮箱已验证", "code": "email_verified"})
+		return
+	}
+
+	latest, err := repository.GetLatestEmailVerification(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "服务器错误"})
+		return
+	}
+	if latest != nil && time.Since(latest.CreatedAt) < emailVerificationCooldown {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "验证邮件发送过于频繁，请稍后再试", "code": "too_many_requests"})
+		return
+	}
+
+	verification, ok := newEmailVerification(c, user.ID, *user.Email)
+	if !ok {
+		return
+	}
+	if err := repository.CreateEmailVerification(verification); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成验证链接失败"})
+		return
+	}
+	if err := notification.SendVerificationEmail(user, verification); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "发送验证邮件失败"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "验证邮件已发送", "expires_at": verification.ExpiresAt})
+}
+
+// VerifyEmail 通过验证邮件中的链接验证邮箱，无需登录
+func VerifyEmail(c *gin.Context) {
+	verification, err := repository.GetEmailVerificationByToken(c.Param("token"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "服务器错误"})
+		return
+	}
+	if verification == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "验证链接无效"})
+		return
+	}
+	if verification.IsExpired() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证链接已过期，请重新发送验证邮件", "code": "verification_expired"})
+		return
+	}
+
+	user, err := repository.GetUserByID(verification.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取用户信息失败"})
+		return
+	}
+	// 验证邮件发出后又修改了邮箱
+	if user == nil || user.Email == nil || *user.Email != verification.Email {
+		c.JSON(http.StatusNotFound, gin.H{"error": "验证链接无效"})
+		return
+	}
+
+	unsubscribeToken, err := newEmailToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "服务器错误"})
+		return
+	}
+	if err := repository.VerifyUserEmail(user, unsubscribeToken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "验证邮箱失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "邮箱已验证", "email": verification.Email})
+}
+
+// Unsubscribe 通过邮件中的退订链接退订邮件，无需登录。查询参数type为deadline_soon、digest或all（默认）。
+// 同时支持邮件客户端的一键退订（POST）
+func Unsubscribe(c *gin.Context) {
+	kind := c.DefaultQuery("type", notification.UnsubscribeAll)
+	if kind != notification.UnsubscribeDeadline && kind != notification.UnsubscribeDigest && kind != notification.UnsubscribeAll {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的退订类型: " + kind})
+		return
+	}
+
+	user, err := repository.GetUserByUnsubscribeToken(c.Param("token"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "服务器错误"})
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "退订链接无效"})
+		return
+	}
+
+	preference, err := repository.GetNotificationPreference(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取通知偏好失败"})
+		return
+	}
+	if kind != notification.UnsubscribeDigest {
+		preference.EmailDeadlineSoon = false
+	}
+	if kind != notification.UnsubscribeDeadline {
+		preference.EmailDigest = models.DigestOff
+	}
+	if err := repository.SaveNotificationPreference(&preference); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "退订失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":             "已退订",
+		"email_deadline_soon": preference.EmailDeadlineSoon,
+		"email_digest":        preference.EmailDigest,
+	})
+}
+
+// loadCurrentUser 加载当前用户，失败时已写入响应
+func loadCurrentUser(c *gin.Context) (*models.User, bool) {
+	user, err := repository.GetUserByID(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取用户信息失败"})
+		return nil, false
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return nil, false
+	}
+	return user, true
+}
+
+// newEmailVerification 为邮箱生成验证令牌，失败时已写入响应
+func newEmailVerification(c *gin.Context, userID uint, email string) (*models.EmailVerification, bool) {
+	token, err := newEmailToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成验证链接失败"})
+		return nil, false
+	}
+	return &models.EmailVerification{
+		UserID:    userID,
+		Email:     email,
+		Token:     token,
+		ExpiresAt: time.Now().Add(models.EmailVerificationTTL),
+	}, true
+}
+
+// newEmailToken 生成邮件链接中使用的随机令牌
+func newEmailToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// emailStatus 邮箱设置的响应内容
+func emailStatus(user *models.User) gin.H {
+	return gin.H{
+		"email":       user.Email,
+		"verified":    user.HasVerifiedEmail(),
+		"verified_at": user.EmailVerifiedAt,
+	}
+}
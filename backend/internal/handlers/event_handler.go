@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"project_management/internal/middleware"
+	"project_management/internal/models"
+	"project_management/internal/repository"
+	"project_management/internal/stream"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// 事件流的心跳间隔
+const eventHeartbeatInterval = 15 * time.Second
+
+// 断线重连时最多补发的事件数，超过时发送reset事件通知客户端重新加载
+const maxReplayEvents = 500
+
+// 建议客户端断线后重连的等待时间（毫秒）
+const eventRetryMillis = 3000
+
+// 事件流连接缓存项目成员身份检查结果的时长
+const eventVisibilityTTL = 30 * time.Second
+
+// StreamEvents 以Server-Sent Events推送当前用户可查看的项目中任务和里程碑的变更，事件ID为操作记录ID。
+// 查询参数project_id（逗号分隔或重复传入）限定项目；通过Last-Event-ID请求头或last_event_id参数
+// 补发断线期间的事件。访问令牌过期时发送token_expired事件并结束连接
+func StreamEvents(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	var projectIDs []uint
+	for _, value := range queryList(c, "project_id") {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+			return
+		}
+		if !middleware.CheckProjectPermission(c, uint(id), models.PermissionView) {
+			return
+		}
+		projectIDs = append(projectIDs, uint(id))
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	var lastID uint64
+	if lastEventID != "" {
+		id, err := strconv.ParseUint(lastEventID, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的事件ID"})
+			return
+		}
+		lastID = id
+	}
+
+	// 先订阅再补发，避免补发期间产生的事件丢失
+	broker := stream.Current()
+	sub := broker.Subscribe()
+	defer broker.Unsubscribe(sub)
+
+	var replay []models.Activity
+	if lastEventID != "" {
+		scope := projectIDs
+		var err error
+		if scope == nil {
+			scope, err = repository.GetUserProjectIDs(userID)
+		}
+		if err == nil {
+			replay, err = repository.GetActivitiesAfter(scope, uint(lastID), maxReplayEvents+1)
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取事件失败"})
+			return
+		}
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	writeEvent(c.Writer, sse.Event{Event: "connected", Retry: eventRetryMillis, Data: gin.H{"last_event_id": lastID}})
+
+	visible := eventVisibility(userID, projectIDs)
+	// 操作记录提交后才推送，ID较小的事件可能晚于ID较大的事件到达，
+	// 因此实时事件只与补发过的事件去重，不能按已发送的最大ID过滤
+	replayed := make(map[uint]struct{}, len(replay))
+	if len(replay) > maxReplayEvents {
+		// 错过的事件过多，由客户端重新加载数据，之后只推送新事件
+		writeEvent(c.Writer, sse.Event{Event: "reset", Data: gin.H{"reason": "too_many_missed_events"}})
+	} else {
+		for i := range replay {
+			writeActivityEvent(c.Writer, &replay[i])
+			replayed[replay[i].ID] = struct{}{}
+		}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	var expired <-chan time.Time
+	if expiresAt, ok := c.Get("tokenExpiresAt"); ok {
+		timer := time.NewTimer(time.Until(expiresAt.(time.Time)))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case activity, ok := <-sub.Events:
+			if !ok {
+				// 服务关闭或推送积压，客户端重连后从最后的事件ID补发
+				return
+			}
+			if _, ok := replayed[activity.ID]; ok {
+				// 每条操作记录只推送一次，去重后不再需要保留
+				delete(replayed, activity.ID)
+				continue
+			}
+			if !visible(activity.ProjectID) {
+				continue
+			}
+			writeActivityEvent(c.Writer, &activity)
+		case <-heartbeat.C:
+			io.WriteString(c.Writer, ": heartbeat\n\n")
+		case <-expired:
+			writeEvent(c.Writer, sse.Event{Event: "token_expired", Data: gin.H{"error": "令牌已过期"}})
+			c.Writer.Flush()
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+// eventVisibility 返回判断用户能否收到某个项目事件的函数。每个连接按项目缓存成员身份的检查结果，
+// 避免每个事件都查询数据库，缓存过期后重新检查，被移出项目后最多eventVisibilityTTL后不再收到该项目的事件。
+// 返回的函数只能在同一个goroutine中使用
+func eventVisibility(userID uint, projectIDs []uint) func(projectID uint) bool {
+	type entry struct {
+		visible   bool
+		checkedAt time.Time
+	}
+	cache := make(map[uint]entry)
+
+	return func(projectID uint) bool {
+		if projectIDs != nil && !slices.Contains(projectIDs, projectID) {
+			return false
+		}
+		if cached, ok := cache[projectID]; ok && time.Since(cached.checkedAt) < eventVisibilityTTL {
+			return cached.visible
+		}
+		member, err := repository.GetProjectMember(projectID, userID)
+		if err != nil {
+			log.Printf("检查事件权限失败: 项目 %d 用户 %d: %v", projectID, userID, err)
+			return false
+		}
+		visible := member != nil && member.Role.Can(models.PermissionView)
+		cache[projectID] = entry{visible: visible, checkedAt: time.Now()}
+		return visible
+	}
+}
+
+// writeActivityEvent 以操作记录ID为事件ID、task.updated等为事件名写入操作记录
+func writeActivityEvent(w io.Writer, activity *models.Activity) {
+	writeEvent(w, sse.Event{Id: strconv.FormatUint(uint64(activity.ID), 10), Event: activity.EventName(), Data: activity})
+}
+
+// writeEvent 写入一条SSE事件，没有ID的事件不改变客户端记录的最后事件ID
+func writeEvent(w io.Writer, event sse.Event) {
+	if err := sse.Encode(w, event); err != nil {
+		log.Printf("写入事件失败: %s: %v", event.Event, err)
+	}
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"project_management/internal/models"
+	"project_management/internal/repository"
+	"project_management/internal/stream"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupTestDB 使用临时SQLite数据库替换repository.DB，测试结束后恢复
+func setupTestDB(t *testing.T, tables ...interface{}) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(tables...); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	previous := repository.DB
+	repository.DB = db
+	t.Cleanup(func() { repository.DB = previous })
+}
+
+func TestStreamEventsDelivery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// 用户1是项目1的成员，不是项目2的成员
+	tests := []struct {
+		name        string
+		lastEventID string
+		stored      []uint // 连接前已提交的项目1操作记录
+		published   []models.Activity
+		want        []uint
+	}{
+		{
+			name:      "ID较小的事件晚到时仍然推送",
+			published: []models.Activity{newTestActivity(11, 1), newTestActivity(12, 2), newTestActivity(10, 1)},
+			want:      []uint{11, 10},
+		},
+		{
+			name:        "补发过的事件不重复推送",
+			lastEventID: "4",
+			stored:      []uint{3, 5, 6},
+			published:   []models.Activity{newTestActivity(6, 1), newTestActivity(7, 1)},
+			want:        []uint{5, 6, 7},
+		},
+		{
+			name:        "补发时尚未提交的事件之后推送",
+			lastEventID: "4",
+			stored:      []uint{6},
+			published:   []models.Activity{newTestActivity(6, 1), newTestActivity(5, 1)},
+			want:        []uint{6, 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestDB(t, &models.ProjectMember{}, &models.Activity{})
+			repository.DB.Create(&models.ProjectMember{ProjectID: 1, UserID: 1, Role: models.ProjectRoleViewer})
+			for _, id := range tt.stored {
+				a := newTestActivity(id, 1)
+				repository.DB.Create(&a)
+			}
+
+			router := gin.New()
+			router.GET("/events", func(c *gin.Context) { c.Set("userID", uint(1)) }, StreamEvents)
+			server := httptest.NewServer(router)
+			defer server.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			url := server.URL + "/events"
+			if tt.lastEventID != "" {
+				url += "?last_event_id=" + tt.lastEventID
+			}
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("connect: %v", err)
+			}
+			defer resp.Body.Close()
+
+			events := readEvents(resp)
+			if event, ok := <-events; !ok || event.name != "connected" {
+				t.Fatalf("first event = %+v, want connected", event)
+			}
+
+			// 连接建立后已经订阅，最后发送一条标记事件，收到它时之前的事件都已处理
+			const sentinel = 99
+			stream.Current().Publish(append(tt.published, newTestActivity(sentinel, 1))...)
+
+			var got []uint
+			for event := range events {
+				id, _ := strconv.ParseUint(event.id, 10, 32)
+				if id == sentinel {
+					break
+				}
+				got = append(got, uint(id))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("received events %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// sseEvent 从事件流中读取的一条事件
+type sseEvent struct {
+	id   string
+	name string
+}
+
+// readEvents 逐条读取响应中的SSE事件，忽略注释行
+func readEvents(resp *http.Response) <-chan sseEvent {
+	events := make(chan sseEvent)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(resp.Body)
+		var current sseEvent
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if current.name != "" {
+					events <- current
+				}
+				current = sseEvent{}
+			case strings.HasPrefix(line, "id:"):
+				current.id = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
+			case strings.HasPrefix(line, "event:"):
+				current.name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+			}
+		}
+	}()
+	return events
+}
+
+// newTestActivity 创建项目中任务的修改记录
+func newTestActivity(id, projectID uint) models.Activity {
+	return models.Activity{ID: id, ProjectID: projectID, EntityType: models.EntityTask, EntityID: 1, Action: models.ActivityUpdate}
+}
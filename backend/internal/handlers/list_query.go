@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"project_management/internal/middleware"
+	"project_management/internal/models"
+	"project_management/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListResponse 分页列表响应结构
+type ListResponse struct {
+	Items    interface{} `json:"items"`
+	Total    int64       `json:"total"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+}
+
+// listTasks 解析查询参数中的筛选、排序和分页条件，在base限定的范围内查询任务并写入响应。
+// 筛选参数见parseTaskFilter，另支持sort、order、page、page_size
+func listTasks(c *gin.Context, base repository.TaskFilter) {
+	filter, ok := parseTaskFilter(c, base)
+	if !ok {
+		return
+	}
+
+	opts, ok := parseListOptions(c)
+	if !ok {
+		return
+	}
+
+	tasks, total, err := repository.ListTasks(filter, opts)
+	if err != nil {
+		writeListError(c, err, "获取任务失败")
+		return
+	}
+
+	if err := attachTaskRollups(tasks); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取子任务汇总失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ListResponse{Items: tasks, Total: total, Page: opts.Page, PageSize: opts.PageSize})
+}
+
+// parseTaskFilter 在base的基础上解析任务筛选参数，失败时已写入响应。
+// 支持的参数：status、urgency（逗号分隔或重复传入）、assignee_id（用户ID或me）、
+// milestone_id（里程碑ID或none）、parent_id（父任务ID或none）、deadline_from、deadline_to、q
+func parseTaskFilter(c *gin.Context, base repository.TaskFilter) (repository.TaskFilter, bool) {
+	filter := base
+
+	for _, s := range queryList(c, "status") {
+		status := models.TaskStatus(s)
+		if !status.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务状态: " + s})
+			return filter, false
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+
+	for _, u := range queryList(c, "urgency") {
+		urgency := models.TaskUrgency(u)
+		if !urgency.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的紧急程度: " + u})
+			return filter, false
+		}
+		filter.Urgencies = append(filter.Urgencies, urgency)
+	}
+
+	if assignee := c.Query("assignee_id"); assignee != "" && filter.AssigneeID == nil {
+		if assignee == "me" {
+			userID := c.GetUint("userID")
+			filter.AssigneeID = &userID
+		} else {
+			id, err := strconv.ParseUint(assignee, 10, 32)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的负责人ID"})
+				return filter, false
+			}
+			userID := uint(id)
+			filter.AssigneeID = &userID
+		}
+	}
+
+	if milestone := c.Query("milestone_id"); milestone != "" && filter.MilestoneID == nil {
+		if milestone == "none" {
+			filter.NoMilestone = true
+		} else {
+			id, err := strconv.ParseUint(milestone, 10, 32)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的里程碑ID"})
+				return filter, false
+			}
+			milestoneID := uint(id)
+			filter.MilestoneID = &milestoneID
+		}
+	}
+
+	if parent := c.Query("parent_id"); parent != "" && filter.ParentID == nil {
+		if parent == "none" {
+			filter.NoParent = true
+		} else {
+			id, err := strconv.ParseUint(parent, 10, 32)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的父任务ID"})
+				return filter, false
+			}
+			parentID := uint(id)
+			filter.ParentID = &parentID
+		}
+	}
+
+	var ok bool
+	if filter.DeadlineFrom, ok = queryDate(c, "deadline_from"); !ok {
+		return filter, false
+	}
+	if filter.DeadlineTo, ok = queryDate(c, "deadline_to"); !ok {
+		return filter, false
+	}
+	filter.Search = strings.TrimSpace(c.Query("q"))
+	return filter, true
+}
+
+// listMilestones 解析查询参数中的筛选、排序和分页条件，在base限定的范围内查询里程碑并写入响应。
+// 筛选参数见parseMilestoneFilter，另支持sort、order、page、page_size
+func listMilestones(c *gin.Context, base repository.MilestoneFilter) {
+	filter, ok := parseMilestoneFilter(c, base)
+	if !ok {
+		return
+	}
+
+	opts, ok := parseListOptions(c)
+	if !ok {
+		return
+	}
+
+	milestones, total, err := repository.ListMilestones(filter, opts)
+	if err != nil {
+		writeListError(c, err, "获取里程碑失败")
+		return
+	}
+
+	if err := attachMilestoneProgress(milestones); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取里程碑进度失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ListResponse{Items: milestones, Total: total, Page: opts.Page, PageSize: opts.PageSize})
+}
+
+// parseMilestoneFilter 在base的基础上解析里程碑筛选参数，失败时已写入响应。
+// 支持的参数：date_from、date_to、q
+func parseMilestoneFilter(c *gin.Context, base repository.MilestoneFilter) (repository.MilestoneFilter, bool) {
+	filter := base
+
+	var ok bool
+	if filter.DateFrom, ok = queryDate(c, "date_from"); !ok {
+		return filter, false
+	}
+	if filter.DateTo, ok = queryDate(c, "date_to"); !ok {
+		return filter, false
+	}
+	filter.Search = strings.TrimSpace(c.Query("q"))
+	return filter, true
+}
+
+// queryProjectScope 读取project_id参数限定的项目，未提供时为当前用户参与的全部项目，失败时已写入响应
+func queryProjectScope(c *gin.Context, message string) ([]uint, bool) {
+	if projectID := c.Query("project_id"); projectID != "" {
+		id, err := strconv.ParseUint(projectID, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+			return nil, false
+		}
+		if !middleware.CheckProjectPermission(c, uint(id), models.PermissionView) {
+			return nil, false
+		}
+		return []uint{uint(id)}, true
+	}
+
+	projectIDs, err := repository.GetUserProjectIDs(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+		return nil, false
+	}
+	return projectIDs, true
+}
+
+// listActivities 解析查询参数中的筛选、排序和分页条件，在base限定的范围内查询操作记录并写入响应。
+// 支持的参数：entity_type、action（逗号分隔或重复传入）、actor_id（用户ID或me）、from、to、sort、order、page、page_size
+func listActivities(c *gin.Context, base repository.ActivityFilter) {
+	filter := base
+
+	if entityType := c.Query("entity_type"); entityType != "" && filter.EntityType == "" {
+		filter.EntityType = models.EntityType(entityType)
+		if !filter.EntityType.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的实体类型: " + entityType})
+			return
+		}
+	}
+
+	for _, a := range queryList(c, "action") {
+		action := models.ActivityAction(a)
+		if !action.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的操作类型: " + a})
+			return
+		}
+		filter.Actions = append(filter.Actions, action)
+	}
+
+	if actor := c.Query("actor_id"); actor != "" {
+		if actor == "me" {
+			userID := c.GetUint("userID")
+			filter.ActorID = &userID
+		} else {
+			id, err := strconv.ParseUint(actor, 10, 32)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的操作人ID"})
+				return
+			}
+			actorID := uint(id)
+			filter.ActorID = &actorID
+		}
+	}
+
+	var ok bool
+	if filter.From, ok = queryDate(c, "from"); !ok {
+		return
+	}
+	if filter.To, ok = queryDate(c, "to"); !ok {
+		return
+	}
+	if filter.To != nil {
+		// to为包含的日期，转换为次日零点作为上限
+		end := filter.To.AddDate(0, 0, 1)
+		filter.To = &end
+	}
+
+	opts, ok := parseListOptions(c)
+	if !ok {
+		return
+	}
+
+	activities, total, err := repository.ListActivities(filter, opts)
+	if err != nil {
+		writeListError(c, err, "获取操作记录失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, ListResponse{Items: activities, Total: total, Page: opts.Page, PageSize: opts.PageSize})
+}
+
+// parseListOptions 解析排序和分页参数，失败时已写入响应
+func parseListOptions(c *gin.Context) (repository.ListOptions, bool) {
+	opts := repository.ListOptions{
+		Sort:  c.Query("sort"),
+		Order: c.Query("order"),
+	}
+
+	if page := c.Query("page"); page != "" {
+		n, err := strconv.Atoi(page)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的页码"})
+			return opts, false
+		}
+		opts.Page = n
+	}
+
+	if pageSize := c.Query("page_size"); pageSize != "" {
+		n, err := strconv.Atoi(pageSize)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的每页数量"})
+			return opts, false
+		}
+		opts.PageSize = n
+	}
+
+	opts.Normalize()
+	return opts, true
+}
+
+// queryList 读取可重复或以逗号分隔的查询参数
+func queryList(c *gin.Context, key string) []string {
+	var values []string
+	for _, raw := range c.QueryArray(key) {
+		for _, v := range strings.Split(raw, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+// queryDate 读取2006-01-02格式的日期参数，参数为空时返回nil，格式错误时已写入响应
+func queryDate(c *gin.Context, key string) (*time.Time, bool) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, true
+	}
+
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式: " + key})
+		return nil, false
+	}
+	return &date, true
+}
+
+// writeListError 写入列表查询的错误响应，排序参数错误返回400
+func writeListError(c *gin.Context, err error, message string) {
+	if errors.Is(err, repository.ErrInvalidSortField) || errors.Is(err, repository.ErrInvalidSortOrder) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
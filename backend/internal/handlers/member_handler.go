@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"net/http"
+	"project_management/internal/models"
+	"project_management/internal/repository"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 添加成员请求结构
+type AddMemberRequest struct {
+	UserID   uint               `json:"user_id"`
+	Username string             `json:"username"`
+	Role     models.ProjectRole `json:"role" binding:"required"`
+}
+
+// 修改成员角色请求结构
+type UpdateMemberRequest struct {
+	Role models.ProjectRole `json:"role" binding:"required"`
+}
+
+// GetProjectMembers 获取项目成员列表
+func GetProjectMembers(c *gin.Context) {
+	project, ok := loadProject(c)
+	if !ok {
+		return
+	}
+
+	members, err := repository.GetProjectMembers(project.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目成员失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+// AddProjectMember 添加项目成员
+func AddProjectMember(c *gin.Context) {
+	project, ok := loadProject(c)
+	if !ok {
+		return
+	}
+
+	var req AddMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	if !req.Role.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的成员角色"})
+		return
+	}
+
+	// 只有所有者可以授予所有者角色
+	if req.Role == models.ProjectRoleOwner && c.MustGet("projectRole") != models.ProjectRoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只有项目所有者可以添加所有者", "code": "insufficient_permission"})
+		return
+	}
+
+	// 查找用户，支持用户ID或用户名
+	var user *models.User
+	var err error
+	if req.UserID != 0 {
+		user, err = repository.GetUserByID(req.UserID)
+	} else {
+		user, err = repository.GetUserByUsername(req.Username)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取用户信息失败"})
+		return
+	}
+
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+
+	// 检查是否已是成员
+	existingMember, err := repository.GetProjectMember(project.ID, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目成员失败"})
+		return
+	}
+
+	if existingMember != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "该用户已是项目成员"})
+		return
+	}
+
+	member := &models.ProjectMember{
+		ProjectID: project.ID,
+		UserID:    user.ID,
+		Role:      req.Role,
+	}
+
+	if err := repository.AddProjectMember(member); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "添加项目成员失败"})
+		return
+	}
+
+	member.User = user
+	c.JSON(http.StatusCreated, member)
+}
+
+// UpdateProjectMember 修改项目成员角色
+func UpdateProjectMember(c *gin.Context) {
+	project, ok := loadProject(c)
+	if !ok {
+		return
+	}
+
+	member, ok := loadProjectMember(c, project.ID)
+	if !ok {
+		return
+	}
+
+	var req UpdateMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	if !req.Role.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的成员角色"})
+		return
+	}
+
+	// 授予或撤销所有者角色需要所有者权限
+	isOwner := c.MustGet("projectRole") == models.ProjectRoleOwner
+	if (req.Role == models.ProjectRoleOwner || member.Role == models.ProjectRoleOwner) && !isOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "只有项目所有者可以变更所有者角色", "code": "insufficient_permission"})
+		return
+	}
+
+	// 项目至少保留一名所有者
+	if member.Role == models.ProjectRoleOwner && req.Role != models.ProjectRoleOwner && !checkNotLastOwner(c, project.ID) {
+		return
+	}
+
+	member.Role = req.Role
+	if err := repository.UpdateProjectMember(member); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "修改成员角色失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+// RemoveProjectMember 移除项目成员
+func RemoveProjectMember(c *gin.Context) {
+	project, ok := loadProject(c)
+	if !ok {
+		return
+	}
+
+	member, ok := loadProjectMember(c, project.ID)
+	if !ok {
+		return
+	}
+
+	if member.Role == models.ProjectRoleOwner {
+		if c.MustGet("projectRole") != models.ProjectRoleOwner {
+			c.JSON(http.StatusForbidden, gin.H{"error": "只有项目所有者可以移除所有者", "code": "insufficient_permission"})
+			return
+		}
+		if !checkNotLastOwner(c, project.ID) {
+			return
+		}
+	}
+
+	if err := repository.DeleteProjectMember(project.ID, member.UserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "移除项目成员失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "成员已移除"})
+}
+
+// loadProjectMember 解析路径中的用户ID并加载项目成员，失败时已写入响应
+func loadProjectMember(c *gin.Context, projectID uint) (*models.ProjectMember, bool) {
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的用户ID"})
+		return nil, false
+	}
+
+	member, err := repository.GetProjectMember(projectID, uint(userID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目成员失败"})
+		return nil, false
+	}
+
+	if member == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "项目成员不存在"})
+		return nil, false
+	}
+
+	return member, true
+}
+
+// checkNotLastOwner 检查项目是否还有其他所有者，失败时已写入响应
+func checkNotLastOwner(c *gin.Context, projectID uint) bool {
+	owners, err := repository.CountProjectOwners(projectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目成员失败"})
+		return false
+	}
+
+	if owners <= 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "项目至少需要保留一名所有者", "code": "last_owner"})
+		return false
+	}
+
+	return true
+}
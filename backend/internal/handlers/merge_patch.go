@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// bindMergePatch 按JSON Merge Patch（RFC 7396）将请求体合并到req上，req需预先填充现有数据，
+// 请求体中未出现的字段保持不变，嵌套的对象逐层合并。值为null的字段清空为零值，但只有指针、切片类型的字段
+// 和nullable中列出的字段允许为null，嵌套字段以点号连接，如meta.note。合并后按binding标签校验，失败时已写入响应
+func bindMergePatch(c *gin.Context, req interface{}, nullable ...string) bool {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return false
+	}
+
+	var patch map[string]json.RawMessage
+	if err := json.Unmarshal(body, &patch); err != nil || patch == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求体必须是JSON对象"})
+		return false
+	}
+	if err := json.Unmarshal(body, req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return false
+	}
+
+	// json.Unmarshal遇到null时不会修改字符串等字段，需按字段清空
+	if name, ok := clearNullFields(reflect.ValueOf(req).Elem(), patch, "", nullable); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "字段" + name + "不能为null"})
+		return false
+	}
+
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return false
+	}
+	return true
+}
+
+// clearNullFields 将patch中值为null的字段清空为零值，并递归处理值为对象的嵌套字段。
+// prefix为嵌套字段的路径前缀，字段不允许为null时返回该字段的完整名称和false
+func clearNullFields(value reflect.Value, patch map[string]json.RawMessage, prefix string, nullable []string) (string, bool) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		key := strings.Split(field.Tag.Get("json"), ",")[0]
+		raw, ok := patch[key]
+		if !ok {
+			continue
+		}
+		name := prefix + key
+
+		if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			kind := field.Type.Kind()
+			if kind != reflect.Ptr && kind != reflect.Slice && !slices.Contains(nullable, name) {
+				return name, false
+			}
+			value.Field(i).Set(reflect.Zero(field.Type))
+			continue
+		}
+
+		nested := value.Field(i)
+		if nested.Kind() == reflect.Ptr && !nested.IsNil() {
+			nested = nested.Elem()
+		}
+		if nested.Kind() != reflect.Struct {
+			continue
+		}
+		var nestedPatch map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &nestedPatch); err != nil {
+			continue
+		}
+		if name, ok := clearNullFields(nested, nestedPatch, name+".", nullable); !ok {
+			return name, false
+		}
+	}
+	return "", true
+}
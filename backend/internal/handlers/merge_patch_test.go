@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// patchTarget 测试合并用的请求结构
+type patchTarget struct {
+	Name     string        `json:"name" binding:"required"`
+	Note     string        `json:"note"`
+	Count    int           `json:"count"`
+	OwnerID  *uint         `json:"owner_id"`
+	Tags     []string      `json:"tags"`
+	Settings patchSettings `json:"settings"`
+	Extra    *patchExtra   `json:"extra"`
+}
+
+type patchSettings struct {
+	Color  string `json:"color"`
+	Notify bool   `json:"notify"`
+	Label  string `json:"label"`
+}
+
+type patchExtra struct {
+	Link  string `json:"link"`
+	Order int    `json:"order"`
+}
+
+func TestBindMergePatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ownerID := uint(7)
+	existing := func() patchTarget {
+		return patchTarget{
+			Name:     "发布",
+			Note:     "备注",
+			Count:    2,
+			OwnerID:  &ownerID,
+			Tags:     []string{"a", "b"},
+			Settings: patchSettings{Color: "red", Notify: true, Label: "标签"},
+			Extra:    &patchExtra{Link: "https://example.com", Order: 1},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		nullable   []string
+		want       func(*patchTarget)
+		wantStatus int
+		wantError  string
+	}{
+		{
+			name: "空对象保持不变",
+			body: `{}`,
+			want: func(*patchTarget) {},
+		},
+		{
+			name: "只修改出现的字段",
+			body: `{"note":"新备注","count":0}`,
+			want: func(p *patchTarget) { p.Note, p.Count = "新备注", 0 },
+		},
+		{
+			name: "指针和切片字段为null时清空",
+			body: `{"owner_id":null,"tags":null}`,
+			want: func(p *patchTarget) { p.OwnerID, p.Tags = nil, nil },
+		},
+		{
+			name: "切片整体替换",
+			body: `{"tags":["c"]}`,
+			want: func(p *patchTarget) { p.Tags = []string{"c"} },
+		},
+		{
+			name:     "nullable中的字段为null时清空",
+			body:     `{"note":null}`,
+			nullable: []string{"note"},
+			want:     func(p *patchTarget) { p.Note = "" },
+		},
+		{
+			name:       "其他字段不能为null",
+			body:       `{"count":null}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "字段count不能为null",
+		},
+		{
+			name: "嵌套对象逐层合并",
+			body: `{"settings":{"color":"blue"},"extra":{"order":2}}`,
+			want: func(p *patchTarget) { p.Settings.Color, p.Extra.Order = "blue", 2 },
+		},
+		{
+			name:     "嵌套字段为null时清空",
+			body:     `{"settings":{"label":null}}`,
+			nullable: []string{"settings.label"},
+			want:     func(p *patchTarget) { p.Settings.Label = "" },
+		},
+		{
+			name:       "嵌套字段不能为null",
+			body:       `{"settings":{"notify":null}}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "字段settings.notify不能为null",
+		},
+		{
+			name:       "嵌套字段的nullable需要完整路径",
+			body:       `{"settings":{"label":null}}`,
+			nullable:   []string{"label"},
+			wantStatus: http.StatusBadRequest,
+			wantError:  "字段settings.label不能为null",
+		},
+		{
+			name: "嵌套指针为null时清空",
+			body: `{"extra":null}`,
+			want: func(p *patchTarget) { p.Extra = nil },
+		},
+		{
+			name:       "请求体不是对象",
+			body:       `["name"]`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "请求体必须是JSON对象",
+		},
+		{
+			name:       "请求体为null",
+			body:       `null`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "请求体必须是JSON对象",
+		},
+		{
+			name:       "字段类型错误",
+			body:       `{"count":"3"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "无效的请求数据",
+		},
+		{
+			name:       "合并后校验失败",
+			body:       `{"name":""}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "无效的请求数据",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(tt.body))
+
+			got := existing()
+			ok := bindMergePatch(c, &got, tt.nullable...)
+			if tt.wantStatus != 0 {
+				if ok || w.Code != tt.wantStatus {
+					t.Fatalf("bindMergePatch() = %v, status %d, want false, %d", ok, w.Code, tt.wantStatus)
+				}
+				var body struct {
+					Error string `json:"error"`
+				}
+				json.Unmarshal(w.Body.Bytes(), &body)
+				if body.Error != tt.wantError {
+					t.Errorf("error = %q, want %q", body.Error, tt.wantError)
+				}
+				return
+			}
+
+			if !ok {
+				t.Fatalf("bindMergePatch() = false, response %d %s", w.Code, w.Body)
+			}
+			want := existing()
+			tt.want(&want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("merged = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"project_management/internal/middleware"
 	"project_management/internal/models"
 	"project_management/internal/repository"
 	"strconv"
@@ -12,20 +13,21 @@ import (
 
 // 里程碑请求结构
 type MilestoneRequest struct {
+	ProjectID   uint   `json:"project_id"`
 	Title       string `json:"title" binding:"required"`
 	Date        string `json:"date" binding:"required"`
 	Description string `json:"description"`
+	Version     *uint  `json:"version"` // 更新时期望的版本号，可改用If-Match请求头
 }
 
-// GetAllMilestones 获取所有里程碑
+// GetAllMilestones 获取当前用户参与的所有项目中的里程碑，支持筛选、排序和分页，可通过project_id限定项目
 func GetAllMilestones(c *gin.Context) {
-	milestones, err := repository.GetAllMilestones()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取里程碑失败"})
+	projectIDs, ok := queryProjectScope(c, "获取里程碑失败")
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, milestones)
+	listMilestones(c, repository.MilestoneFilter{ProjectIDs: projectIDs})
 }
 
 // GetMilestoneByID 根据ID获取里程碑
@@ -48,9 +50,49 @@ func GetMilestoneByID(c *gin.Context) {
 		return
 	}
 
+	if !middleware.CheckProjectPermission(c, milestone.ProjectID, models.PermissionView) {
+		return
+	}
+
+	// 计算里程碑进度
+	progress, err := repository.GetMilestoneProgress([]uint{milestone.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取里程碑进度失败"})
+		return
+	}
+	milestone.Progress = progress[milestone.ID]
+
+	setETag(c, milestone.Version)
 	c.JSON(http.StatusOK, milestone)
 }
 
+// GetMilestoneTasks 获取里程碑下的任务，支持筛选、排序和分页
+func GetMilestoneTasks(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的里程碑ID"})
+		return
+	}
+
+	milestone, err := repository.GetMilestoneByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取里程碑失败"})
+		return
+	}
+
+	if milestone == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "里程碑不存在"})
+		return
+	}
+
+	if !middleware.CheckProjectPermission(c, milestone.ProjectID, models.PermissionView) {
+		return
+	}
+
+	listTasks(c, repository.TaskFilter{ProjectIDs: []uint{milestone.ProjectID}, MilestoneID: &milestone.ID})
+}
+
 // CreateMilestone 创建里程碑
 func CreateMilestone(c *gin.Context) {
 	var req MilestoneRequest
@@ -59,55 +101,121 @@ func CreateMilestone(c *gin.Context) {
 		return
 	}
 
+	milestone, ok := prepareMilestoneCreate(c, &req)
+	if !ok {
+		return
+	}
+
+	if err := repository.CreateMilestone(milestone); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建里程碑失败"})
+		return
+	}
+	recordActivity(c, milestone.ProjectID, models.EntityMilestone, milestone.ID, nil, models.MilestoneSnapshot(milestone))
+
+	setETag(c, milestone.Version)
+	c.JSON(http.StatusCreated, milestone)
+}
+
+// prepareMilestoneCreate 校验请求数据并构造待创建的里程碑，不保存到数据库，失败时已写入响应
+func prepareMilestoneCreate(c *gin.Context, req *MilestoneRequest) (*models.Milestone, bool) {
 	// 解析日期
 	date, err := time.Parse("2006-01-02", req.Date)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式"})
-		return
+		return nil, false
+	}
+
+	// 检查所属项目及权限
+	if !checkProjectExists(c, req.ProjectID) {
+		return nil, false
+	}
+	if !middleware.CheckProjectPermission(c, req.ProjectID, models.PermissionEdit) {
+		return nil, false
 	}
 
-	// 创建里程碑
-	milestone := &models.Milestone{
+	return &models.Milestone{
+		ProjectID:   req.ProjectID,
 		Title:       req.Title,
 		Date:        date,
 		Description: req.Description,
+	}, true
+}
+
+// UpdateMilestone 更新里程碑，请求体为完整的里程碑数据
+func UpdateMilestone(c *gin.Context) {
+	existingMilestone, ok := loadMilestoneForUpdate(c)
+	if !ok {
+		return
 	}
 
-	if err := repository.CreateMilestone(milestone); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建里程碑失败"})
+	// 解析请求数据
+	var req MilestoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, milestone)
+	// 检查版本号，防止覆盖他人在此期间的修改
+	if !checkVersion(c, req.Version, existingMilestone.Version, existingMilestone, true) {
+		return
+	}
+
+	applyMilestoneUpdate(c, existingMilestone, &req)
 }
 
-// UpdateMilestone 更新里程碑
-func UpdateMilestone(c *gin.Context) {
+// PatchMilestone 按JSON Merge Patch部分更新里程碑，只修改请求体中出现的字段，description为null时清空，版本号可选
+func PatchMilestone(c *gin.Context) {
+	existingMilestone, ok := loadMilestoneForUpdate(c)
+	if !ok {
+		return
+	}
+
+	req := MilestoneRequest{
+		ProjectID:   existingMilestone.ProjectID,
+		Title:       existingMilestone.Title,
+		Date:        existingMilestone.Date.Format("2006-01-02"),
+		Description: existingMilestone.Description,
+	}
+	if !bindMergePatch(c, &req, "description") {
+		return
+	}
+
+	if !checkVersion(c, req.Version, existingMilestone.Version, existingMilestone, false) {
+		return
+	}
+
+	applyMilestoneUpdate(c, existingMilestone, &req)
+}
+
+// loadMilestoneForUpdate 获取待更新的里程碑并检查编辑权限，失败时已写入响应
+func loadMilestoneForUpdate(c *gin.Context) (*models.Milestone, bool) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的里程碑ID"})
-		return
+		return nil, false
 	}
 
-	// 获取现有里程碑
 	existingMilestone, err := repository.GetMilestoneByID(uint(id))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取里程碑失败"})
-		return
+		return nil, false
 	}
 
 	if existingMilestone == nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "里程碑不存在"})
-		return
+		return nil, false
 	}
 
-	// 解析请求数据
-	var req MilestoneRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
-		return
+	if !middleware.CheckProjectPermission(c, existingMilestone.ProjectID, models.PermissionEdit) {
+		return nil, false
 	}
+	return existingMilestone, true
+}
+
+// applyMilestoneUpdate 校验请求数据并更新里程碑，与创建里程碑使用相同的校验规则
+func applyMilestoneUpdate(c *gin.Context, existingMilestone *models.Milestone, req *MilestoneRequest) {
+	before := models.MilestoneSnapshot(existingMilestone)
 
 	// 解析日期
 	date, err := time.Parse("2006-01-02", req.Date)
@@ -116,6 +224,20 @@ func UpdateMilestone(c *gin.Context) {
 		return
 	}
 
+	// 更换所属项目
+	if req.ProjectID != 0 && req.ProjectID != existingMilestone.ProjectID {
+		if !checkProjectExists(c, req.ProjectID) {
+			return
+		}
+		if !middleware.CheckProjectPermission(c, req.ProjectID, models.PermissionEdit) {
+			return
+		}
+		if !checkMilestoneHasNoTasks(c, existingMilestone) {
+			return
+		}
+		existingMilestone.ProjectID = req.ProjectID
+	}
+
 	// 更新里程碑字段
 	existingMilestone.Title = req.Title
 	existingMilestone.Date = date
@@ -123,14 +245,16 @@ func UpdateMilestone(c *gin.Context) {
 
 	// 保存更新
 	if err := repository.UpdateMilestone(existingMilestone); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新里程碑失败"})
+		writeMilestoneSaveError(c, existingMilestone.ID, err)
 		return
 	}
+	recordActivity(c, existingMilestone.ProjectID, models.EntityMilestone, existingMilestone.ID, before, models.MilestoneSnapshot(existingMilestone))
 
+	setETag(c, existingMilestone.Version)
 	c.JSON(http.StatusOK, existingMilestone)
 }
 
-// DeleteMilestone 删除里程碑
+// DeleteMilestone 将里程碑移入回收站
 func DeleteMilestone(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -151,11 +275,50 @@ func DeleteMilestone(c *gin.Context) {
 		return
 	}
 
+	if !middleware.CheckProjectPermission(c, existingMilestone.ProjectID, models.PermissionEdit) {
+		return
+	}
+
 	// 删除里程碑
 	if err := repository.DeleteMilestone(uint(id)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除里程碑失败"})
 		return
 	}
+	recordActivity(c, existingMilestone.ProjectID, models.EntityMilestone, existingMilestone.ID, models.MilestoneSnapshot(existingMilestone), nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "里程碑已移入回收站"})
+}
+
+// checkMilestoneHasNoTasks 检查里程碑没有关联任务（包括回收站中的任务），任务只能关联同一项目的里程碑，失败时已写入响应
+func checkMilestoneHasNoTasks(c *gin.Context, milestone *models.Milestone) bool {
+	count, err := repository.CountMilestoneTasks(milestone.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取里程碑任务失败"})
+		return false
+	}
+
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "存在关联任务的里程碑不能更换项目", "code": "milestone_has_tasks"})
+		return false
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "里程碑已删除"})
-} 
\ No newline at end of file
+	return true
+}
+
+// attachMilestoneProgress 为里程碑列表填充进度信息
+func attachMilestoneProgress(milestones []models.Milestone) error {
+	ids := make([]uint, len(milestones))
+	for i := range milestones {
+		ids[i] = milestones[i].ID
+	}
+
+	progress, err := repository.GetMilestoneProgress(ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range milestones {
+		milestones[i].Progress = progress[milestones[i].ID]
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"net/http"
+	"project_management/internal/models"
+	"project_management/internal/repository"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 通知偏好请求结构，未传入的字段保持不变
+type NotificationPreferenceRequest struct {
+	Assigned      *bool `json:"assigned"`
+	StatusChanged *bool `json:"status_changed"`
+	DeadlineSoon  *bool `json:"deadline_soon"`
+	Mentioned     *bool `json:"mentioned"`
+	DeadlineHours *int  `json:"deadline_hours"`
+
+	EmailDeadlineSoon *bool                   `json:"email_deadline_soon"`
+	EmailDigest       *models.DigestFrequency `json:"email_digest"`
+}
+
+// GetNotifications 分页获取当前用户的通知，unread=true时只返回未读通知，支持type筛选（逗号分隔或重复传入）
+func GetNotifications(c *gin.Context) {
+	filter := repository.NotificationFilter{
+		UserID:     c.GetUint("userID"),
+		UnreadOnly: c.Query("unread") == "true",
+	}
+	types, ok := parseNotificationTypes(c)
+	if !ok {
+		return
+	}
+	filter.Types = types
+
+	opts, ok := parseListOptions(c)
+	if !ok {
+		return
+	}
+
+	notifications, total, err := repository.ListNotifications(filter, opts)
+	if err != nil {
+		writeListError(c, err, "获取通知失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, ListResponse{Items: notifications, Total: total, Page: opts.Page, PageSize: opts.PageSize})
+}
+
+// GetUnreadNotificationCount 获取当前用户的未读通知总数和按类型的未读数
+func GetUnreadNotificationCount(c *gin.Context) {
+	counts, err := repository.CountUnreadNotifications(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取未读通知数失败"})
+		return
+	}
+
+	var unread int64
+	for _, count := range counts {
+		unread += count
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unread": unread, "by_type": counts})
+}
+
+// MarkNotificationRead 将通知标记为已读
+func MarkNotificationRead(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的通知ID"})
+		return
+	}
+
+	notification, err := repository.GetUserNotification(c.GetUint("userID"), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取通知失败"})
+		return
+	}
+
+	if notification == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "通知不存在"})
+		return
+	}
+
+	if err := repository.MarkNotificationRead(notification); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "标记已读失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, notification)
+}
+
+// MarkAllNotificationsRead 将当前用户的未读通知全部标记为已读，可通过type限定通知类型
+func MarkAllNotificationsRead(c *gin.Context) {
+	types, ok := parseNotificationTypes(c)
+	if !ok {
+		return
+	}
+
+	updated, err := repository.MarkAllNotificationsRead(c.GetUint("userID"), types)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "标记已读失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"updated": updated})
+}
+
+// GetNotificationPreferences 获取当前用户的通知偏好
+func GetNotificationPreferences(c *gin.Context) {
+	preference, err := repository.GetNotificationPreference(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取通知偏好失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, preference)
+}
+
+// UpdateNotificationPreferences 修改当前用户的通知偏好
+func UpdateNotificationPreferences(c *gin.Context) {
+	var req NotificationPreferenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	if req.DeadlineHours != nil && (*req.DeadlineHours < 1 || *req.DeadlineHours > models.MaxDeadlineReminderHours) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "截止提醒时间必须在1到168小时之间"})
+		return
+	}
+
+	if req.EmailDigest != nil && !req.EmailDigest.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的摘要频率: " + string(*req.EmailDigest)})
+		return
+	}
+
+	preference, err := repository.GetNotificationPreference(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取通知偏好失败"})
+		return
+	}
+
+	if req.Assigned != nil {
+		preference.Assigned = *req.Assigned
+	}
+	if req.StatusChanged != nil {
+		preference.StatusChanged = *req.StatusChanged
+	}
+	if req.DeadlineSoon != nil {
+		preference.DeadlineSoon = *req.DeadlineSoon
+	}
+	if req.Mentioned != nil {
+		preference.Mentioned = *req.Mentioned
+	}
+	if req.DeadlineHours != nil {
+		preference.DeadlineHours = *req.DeadlineHours
+	}
+	if req.EmailDeadlineSoon != nil {
+		preference.EmailDeadlineSoon = *req.EmailDeadlineSoon
+	}
+	if req.EmailDigest != nil {
+		preference.EmailDigest = *req.EmailDigest
+	}
+
+	if err := repository.SaveNotificationPreference(&preference); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "修改通知偏好失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, preference)
+}
+
+// parseNotificationTypes 解析type查询参数，失败时已写入响应
+func parseNotificationTypes(c *gin.Context) ([]models.NotificationType, bool) {
+	var types []models.NotificationType
+	for _, value := range queryList(c, "type") {
+		t := models.NotificationType(value)
+		if !t.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的通知类型: " + value})
+			return nil, false
+		}
+		types = append(types, t)
+	}
+	return types, true
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"net/http"
+	"project_management/internal/models"
+	"project_management/internal/repository"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 项目请求结构
+type ProjectRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+// GetAllProjects 获取当前用户参与的所有项目
+func GetAllProjects(c *gin.Context) {
+	projects, err := repository.GetProjectsByUser(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, projects)
+}
+
+// GetProjectByID 根据ID获取项目
+func GetProjectByID(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+		return
+	}
+
+	project, err := repository.GetProjectByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目失败"})
+		return
+	}
+
+	if project == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "项目不存在"})
+		return
+	}
+
+	c.JSON(http.StatusOK, project)
+}
+
+// CreateProject 创建项目，创建者自动成为项目所有者
+func CreateProject(c *gin.Context) {
+	var req ProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	project := &models.Project{
+		Name:        req.Name,
+		Description: req.Description,
+		OwnerID:     c.GetUint("userID"),
+	}
+
+	if err := repository.CreateProject(project); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建项目失败"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, project)
+}
+
+// UpdateProject 更新项目
+func UpdateProject(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+		return
+	}
+
+	// 获取现有项目
+	existingProject, err := repository.GetProjectByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目失败"})
+		return
+	}
+
+	if existingProject == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "项目不存在"})
+		return
+	}
+
+	// 解析请求数据
+	var req ProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	// 更新项目字段
+	existingProject.Name = req.Name
+	existingProject.Description = req.Description
+
+	// 保存更新
+	if err := repository.UpdateProject(existingProject); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新项目失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, existingProject)
+}
+
+// DeleteProject 删除项目（同时删除项目下的任务和里程碑）
+func DeleteProject(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+		return
+	}
+
+	// 检查项目是否存在
+	existingProject, err := repository.GetProjectByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目失败"})
+		return
+	}
+
+	if existingProject == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "项目不存在"})
+		return
+	}
+
+	// 删除项目，数据库记录删除后再清理附件文件
+	attachmentKeys, err := repository.GetProjectAttachmentKeys(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目附件失败"})
+		return
+	}
+	if err := repository.DeleteProject(uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除项目失败"})
+		return
+	}
+	removeStoredFiles(attachmentKeys)
+
+	c.JSON(http.StatusOK, gin.H{"message": "项目已删除"})
+}
+
+// GetProjectTasks 获取项目下的任务，支持筛选、排序和分页
+func GetProjectTasks(c *gin.Context) {
+	project, ok := loadProject(c)
+	if !ok {
+		return
+	}
+
+	listTasks(c, repository.TaskFilter{ProjectIDs: []uint{project.ID}})
+}
+
+// GetProjectMilestones 获取项目下的里程碑，支持筛选、排序和分页
+func GetProjectMilestones(c *gin.Context) {
+	project, ok := loadProject(c)
+	if !ok {
+		return
+	}
+
+	listMilestones(c, repository.MilestoneFilter{ProjectIDs: []uint{project.ID}})
+}
+
+// loadProject 解析路径中的项目ID并加载项目，失败时已写入响应
+func loadProject(c *gin.Context) (*models.Project, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+		return nil, false
+	}
+
+	project, err := repository.GetProjectByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目失败"})
+		return nil, false
+	}
+
+	if project == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "项目不存在"})
+		return nil, false
+	}
+
+	return project, true
+}
+
+// checkProjectExists 检查任务或里程碑引用的项目是否存在，失败时已写入响应
+func checkProjectExists(c *gin.Context, projectID uint) bool {
+	if projectID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少所属项目"})
+		return false
+	}
+
+	project, err := repository.GetProjectByID(projectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目失败"})
+		return false
+	}
+
+	if project == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "所属项目不存在"})
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// capturedError 校验函数写入的错误响应
+type capturedError struct {
+	Status  int
+	Error   string
+	Code    string
+	Details map[string]interface{} // 响应中除error和code以外的字段
+}
+
+// captureResponse 执行会写入响应的校验函数fn，但不写入真正的响应，用于对多条数据逐条复用单条接口的校验。
+// fn成功时返回nil，否则返回其写入的状态码和错误信息
+func captureResponse(c *gin.Context, fn func() bool) *capturedError {
+	writer := c.Writer
+	recorder := &responseRecorder{ResponseWriter: writer, header: http.Header{}, status: http.StatusOK}
+	c.Writer = recorder
+	ok := fn()
+	c.Writer = writer
+	if ok {
+		return nil
+	}
+
+	failure := &capturedError{Status: recorder.status}
+	var body map[string]interface{}
+	if err := json.Unmarshal(recorder.body.Bytes(), &body); err != nil {
+		failure.Error = recorder.body.String()
+		return failure
+	}
+	if message, ok := body["error"].(string); ok {
+		failure.Error = message
+	}
+	if code, ok := body["code"].(string); ok {
+		failure.Code = code
+	}
+	delete(body, "error")
+	delete(body, "code")
+	if len(body) > 0 {
+		failure.Details = body
+	}
+	return failure
+}
+
+// responseRecorder 记录写入的响应而不发送给客户端
+type responseRecorder struct {
+	gin.ResponseWriter
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header               { return r.header }
+func (r *responseRecorder) WriteHeader(code int)              { r.status = code }
+func (r *responseRecorder) WriteHeaderNow()                   {}
+func (r *responseRecorder) Write(data []byte) (int, error)    { return r.body.Write(data) }
+func (r *responseRecorder) WriteString(s string) (int, error) { return r.body.WriteString(s) }
+func (r *responseRecorder) Status() int                       { return r.status }
+func (r *responseRecorder) Size() int                         { return r.body.Len() }
+func (r *responseRecorder) Written() bool                     { return r.body.Len() > 0 }
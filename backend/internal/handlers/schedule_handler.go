@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"project_management/internal/models"
+	"project_management/internal/planning"
+	"project_management/internal/repository"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetProjectSchedule 计算项目排期，包括各任务的最早和最晚开始、完成日期，总时差和关键路径，
+// 并标记无法达成的里程碑。查询参数start指定排期起点，默认为当天
+func GetProjectSchedule(c *gin.Context) {
+	project, ok := loadProject(c)
+	if !ok {
+		return
+	}
+
+	start := models.OverdueCutoff()
+	if startStr := c.Query("start"); startStr != "" {
+		date, err := time.Parse("2006-01-02", startStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式"})
+			return
+		}
+		start = date
+	}
+
+	tasks, err := repository.GetOpenProjectTasks(project.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务失败"})
+		return
+	}
+
+	dependencies, err := repository.GetProjectDependencies(project.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务依赖失败"})
+		return
+	}
+
+	milestones, err := repository.GetProjectMilestoneList(project.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取里程碑失败"})
+		return
+	}
+
+	schedule, err := planning.Compute(project.ID, start, tasks, dependencies, milestones)
+	if err != nil {
+		log.Printf("计算项目排期失败: project=%d: %v", project.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "计算项目排期失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"project_management/internal/models"
+	"project_management/internal/repository"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 近期里程碑的默认和最大天数
+const (
+	defaultUpcomingDays = 30
+	maxUpcomingDays     = 365
+)
+
+// GetStats 获取当前用户参与的所有项目的任务统计
+func GetStats(c *gin.Context) {
+	projectIDs, err := repository.GetUserProjectIDs(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取统计数据失败"})
+		return
+	}
+
+	writeStats(c, projectIDs)
+}
+
+// GetProjectStats 获取单个项目的任务统计
+func GetProjectStats(c *gin.Context) {
+	project, ok := loadProject(c)
+	if !ok {
+		return
+	}
+
+	writeStats(c, []uint{project.ID})
+}
+
+// writeStats 统计指定项目范围内的数据并写入响应，days参数控制近期里程碑的天数
+func writeStats(c *gin.Context, projectIDs []uint) {
+	days := defaultUpcomingDays
+	if daysStr := c.Query("days"); daysStr != "" {
+		n, err := strconv.Atoi(daysStr)
+		if err != nil || n < 0 || n > maxUpcomingDays {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的天数"})
+			return
+		}
+		days = n
+	}
+
+	upcomingUntil := models.OverdueCutoff().AddDate(0, 0, days)
+	stats, err := repository.GetTaskStats(projectIDs, upcomingUntil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取统计数据失败"})
+		return
+	}
+
+	if err := attachMilestoneProgress(stats.UpcomingMilestones); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取里程碑进度失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
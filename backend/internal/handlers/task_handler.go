@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"project_management/internal/middleware"
 	"project_management/internal/models"
 	"project_management/internal/repository"
+	"project_management/internal/workflow"
 	"strconv"
 	"time"
 
@@ -12,22 +16,39 @@ import (
 
 // 任务请求结构
 type TaskRequest struct {
-	Name     string           `json:"name" binding:"required"`
-	Deadline string           `json:"deadline" binding:"required"`
-	Status   models.TaskStatus `json:"status"`
-	Urgency  models.TaskUrgency `json:"urgency"`
-	Assignee string           `json:"assignee" binding:"required"`
+	ProjectID     uint               `json:"project_id"`
+	MilestoneID   *uint              `json:"milestone_id"`
+	ParentID      *uint              `json:"parent_id"` // 仅创建时使用，修改父任务使用单独的接口
+	Name          string             `json:"name" binding:"required"`
+	StartDate     string             `json:"start_date"` // 为空表示不设置计划开始日期
+	Deadline      string             `json:"deadline" binding:"required"`
+	EstimatedDays int                `json:"estimated_days"` // 为0时创建使用默认值1，更新保持不变
+	Status        models.TaskStatus  `json:"status"`
+	Urgency       models.TaskUrgency `json:"urgency"`
+	AssigneeIDs   []uint             `json:"assignee_ids"`
+	Version       *uint              `json:"version"` // 更新时期望的版本号，可改用If-Match请求头
 }
 
-// GetAllTasks 获取所有任务
+// 任务里程碑请求结构，milestone_id为null表示移出里程碑
+type TaskMilestoneRequest struct {
+	MilestoneID *uint `json:"milestone_id"`
+	Version     *uint `json:"version"` // 可选，提供时检查版本号
+}
+
+// 父任务请求结构，parent_id为null表示移动为顶层任务
+type TaskParentRequest struct {
+	ParentID *uint `json:"parent_id"`
+	Version  *uint `json:"version"` // 可选，提供时检查版本号
+}
+
+// GetAllTasks 获取当前用户参与的所有项目中的任务，支持筛选、排序和分页，可通过project_id限定项目
 func GetAllTasks(c *gin.Context) {
-	tasks, err := repository.GetAllTasks()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务失败"})
+	projectIDs, ok := queryProjectScope(c, "获取任务失败")
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, tasks)
+	listTasks(c, repository.TaskFilter{ProjectIDs: projectIDs})
 }
 
 // GetTaskByID 根据ID获取任务
@@ -50,6 +71,19 @@ func GetTaskByID(c *gin.Context) {
 		return
 	}
 
+	if !middleware.CheckProjectPermission(c, task.ProjectID, models.PermissionView) {
+		return
+	}
+
+	// 计算子任务汇总
+	rollups, err := repository.GetTaskRollups([]uint{task.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取子任务汇总失败"})
+		return
+	}
+	task.Rollup = rollups[task.ID]
+
+	setETag(c, task.Version)
 	c.JSON(http.StatusOK, task)
 }
 
@@ -61,91 +95,436 @@ func CreateTask(c *gin.Context) {
 		return
 	}
 
+	task, ok := prepareTaskCreate(c, &req)
+	if !ok {
+		return
+	}
+
+	if err := repository.CreateTask(task); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建任务失败"})
+		return
+	}
+	recordActivity(c, task.ProjectID, models.EntityTask, task.ID, nil, models.TaskSnapshot(task))
+
+	setETag(c, task.Version)
+	c.JSON(http.StatusCreated, task)
+}
+
+// prepareTaskCreate 校验请求数据并构造待创建的任务，不保存到数据库，失败时已写入响应
+func prepareTaskCreate(c *gin.Context, req *TaskRequest) (*models.Task, bool) {
 	// 解析截止日期
 	deadline, err := time.Parse("2006-01-02", req.Deadline)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式"})
-		return
+		return nil, false
+	}
+	startDate, ok := parseStartDate(c, req.StartDate, deadline)
+	if !ok {
+		return nil, false
 	}
 
-	// 默认值处理
-	if req.Status == "" {
-		req.Status = models.TaskStatusPending
+	// 检查所属项目及权限
+	if !checkProjectExists(c, req.ProjectID) {
+		return nil, false
+	}
+	if !middleware.CheckProjectPermission(c, req.ProjectID, models.PermissionEdit) {
+		return nil, false
+	}
+
+	// 检查关联的里程碑和父任务
+	if !checkTaskMilestone(c, req.ProjectID, req.MilestoneID) {
+		return nil, false
 	}
+	if !checkTaskParent(c, req.ProjectID, 0, req.ParentID) {
+		return nil, false
+	}
+
+	// 检查任务负责人
+	assignees, ok := resolveAssignees(c, req.ProjectID, req.AssigneeIDs)
+	if !ok {
+		return nil, false
+	}
+
+	// 默认值处理
 	if req.Urgency == "" {
 		req.Urgency = models.TaskUrgencyMedium
 	}
+	if !checkTaskUrgency(c, req.Urgency) {
+		return nil, false
+	}
+	if req.EstimatedDays == 0 {
+		req.EstimatedDays = 1
+	}
+	if !checkEstimatedDays(c, req.EstimatedDays) {
+		return nil, false
+	}
 
-	// 创建任务
+	// 构造任务
 	task := &models.Task{
-		Name:     req.Name,
-		Deadline: deadline,
-		Status:   req.Status,
-		Urgency:  req.Urgency,
-		Assignee: req.Assignee,
+		ProjectID:     req.ProjectID,
+		MilestoneID:   req.MilestoneID,
+		ParentID:      req.ParentID,
+		Name:          req.Name,
+		StartDate:     startDate,
+		Deadline:      deadline,
+		EstimatedDays: req.EstimatedDays,
+		Urgency:       req.Urgency,
+		Assignees:     assignees,
 	}
 
-	if err := repository.CreateTask(task); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建任务失败"})
+	// 设置初始状态
+	if err := workflow.Current().Initialize(task, req.Status, time.Now()); err != nil {
+		writeWorkflowError(c, err, "", req.Status)
+		return nil, false
+	}
+	return task, true
+}
+
+// UpdateTask 更新任务，请求体为完整的任务数据
+func UpdateTask(c *gin.Context) {
+	existingTask, ok := loadTaskForUpdate(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusCreated, task)
+	// 解析请求数据
+	var req TaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	// 检查版本号，防止覆盖他人在此期间的修改
+	if !checkVersion(c, req.Version, existingTask.Version, existingTask, true) {
+		return
+	}
+
+	applyTaskUpdate(c, existingTask, &req)
 }
 
-// UpdateTask 更新任务
-func UpdateTask(c *gin.Context) {
+// PatchTask 按JSON Merge Patch部分更新任务，只修改请求体中出现的字段，
+// milestone_id、start_date和assignee_ids为null时清空，版本号可选
+func PatchTask(c *gin.Context) {
+	existingTask, ok := loadTaskForUpdate(c)
+	if !ok {
+		return
+	}
+
+	// 以现有数据为基础合并请求体
+	req := taskRequestFrom(existingTask)
+	if !bindMergePatch(c, &req, "start_date") {
+		return
+	}
+	if req.ParentID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "修改父任务请使用 PUT /api/tasks/:id/parent"})
+		return
+	}
+
+	if !checkVersion(c, req.Version, existingTask.Version, existingTask, false) {
+		return
+	}
+
+	applyTaskUpdate(c, existingTask, &req)
+}
+
+// taskRequestFrom 根据现有任务构造请求数据，状态、紧急程度和预计工期留空表示保持不变
+func taskRequestFrom(task *models.Task) TaskRequest {
+	req := TaskRequest{
+		ProjectID:   task.ProjectID,
+		MilestoneID: task.MilestoneID,
+		Name:        task.Name,
+		Deadline:    task.Deadline.Format("2006-01-02"),
+		AssigneeIDs: make([]uint, len(task.Assignees)),
+	}
+	if task.StartDate != nil {
+		req.StartDate = task.StartDate.Format("2006-01-02")
+	}
+	for i, user := range task.Assignees {
+		req.AssigneeIDs[i] = user.ID
+	}
+	return req
+}
+
+// loadTaskForUpdate 获取路径参数指定的任务并检查编辑权限，失败时已写入响应
+func loadTaskForUpdate(c *gin.Context) (*models.Task, bool) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
-		return
+		return nil, false
 	}
+	return loadEditableTask(c, uint(id))
+}
 
-	// 获取现有任务
-	existingTask, err := repository.GetTaskByID(uint(id))
+// loadEditableTask 获取任务并检查编辑权限，失败时已写入响应
+func loadEditableTask(c *gin.Context, id uint) (*models.Task, bool) {
+	existingTask, err := repository.GetTaskByID(id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务失败"})
-		return
+		return nil, false
 	}
 
 	if existingTask == nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+		return nil, false
+	}
+
+	if !middleware.CheckProjectPermission(c, existingTask.ProjectID, models.PermissionEdit) {
+		return nil, false
+	}
+	return existingTask, true
+}
+
+// applyTaskUpdate 校验请求数据并更新任务，与创建任务使用相同的校验规则
+func applyTaskUpdate(c *gin.Context, existingTask *models.Task, req *TaskRequest) {
+	before := models.TaskSnapshot(existingTask)
+	if !prepareTaskUpdate(c, existingTask, req) {
 		return
 	}
 
-	// 解析请求数据
-	var req TaskRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+	// 保存更新
+	if err := repository.UpdateTask(existingTask); err != nil {
+		writeTaskSaveError(c, existingTask.ID, err)
 		return
 	}
+	recordActivity(c, existingTask.ProjectID, models.EntityTask, existingTask.ID, before, models.TaskSnapshot(existingTask))
+
+	setETag(c, existingTask.Version)
+	c.JSON(http.StatusOK, existingTask)
+}
 
+// prepareTaskUpdate 校验请求数据并修改existingTask的字段，不保存到数据库，失败时已写入响应
+func prepareTaskUpdate(c *gin.Context, existingTask *models.Task, req *TaskRequest) bool {
 	// 解析截止日期
 	deadline, err := time.Parse("2006-01-02", req.Deadline)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式"})
-		return
+		return false
+	}
+	startDate, ok := parseStartDate(c, req.StartDate, deadline)
+	if !ok {
+		return false
+	}
+
+	// 更换所属项目，父子任务必须属于同一项目，因此层级中的任务不能单独更换项目
+	if req.ProjectID != 0 && req.ProjectID != existingTask.ProjectID {
+		if !checkTaskNotInHierarchy(c, existingTask) {
+			return false
+		}
+		if !checkTaskHasNoDependencies(c, existingTask) {
+			return false
+		}
+		if !checkProjectExists(c, req.ProjectID) {
+			return false
+		}
+		if !middleware.CheckProjectPermission(c, req.ProjectID, models.PermissionEdit) {
+			return false
+		}
+		existingTask.ProjectID = req.ProjectID
+	}
+
+	// 检查关联的里程碑
+	if !checkTaskMilestone(c, existingTask.ProjectID, req.MilestoneID) {
+		return false
+	}
+
+	// 检查任务负责人
+	assignees, ok := resolveAssignees(c, existingTask.ProjectID, req.AssigneeIDs)
+	if !ok {
+		return false
+	}
+
+	// 检查紧急程度，为空时保持不变
+	if req.Urgency == "" {
+		req.Urgency = existingTask.Urgency
+	}
+	if !checkTaskUrgency(c, req.Urgency) {
+		return false
+	}
+
+	// 检查预计工期，为0时保持不变
+	if req.EstimatedDays == 0 {
+		req.EstimatedDays = existingTask.EstimatedDays
+	}
+	if !checkEstimatedDays(c, req.EstimatedDays) {
+		return false
+	}
+
+	// 按状态机流转任务状态，为空时保持不变；被未完成任务阻塞时除非force=true否则拒绝开始或完成
+	from := existingTask.Status
+	if req.Status != "" && workflow.Current().CanTransition(from, req.Status) &&
+		!checkTaskNotBlocked(c, existingTask, req.Status, c.Query("force") == "true") {
+		return false
+	}
+	if err := workflow.Current().Transition(existingTask, req.Status, time.Now()); err != nil {
+		writeWorkflowError(c, err, from, req.Status)
+		return false
 	}
 
 	// 更新任务字段
+	existingTask.MilestoneID = req.MilestoneID
 	existingTask.Name = req.Name
+	existingTask.StartDate = startDate
 	existingTask.Deadline = deadline
-	existingTask.Status = req.Status
+	existingTask.EstimatedDays = req.EstimatedDays
 	existingTask.Urgency = req.Urgency
-	existingTask.Assignee = req.Assignee
+	existingTask.Assignees = assignees
+	return true
+}
 
-	// 保存更新
+// DeleteTask 将任务移入回收站。查询参数cascade=true时一并删除全部下级任务，否则子任务上移一级
+func DeleteTask(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+
+	// 检查任务是否存在
+	existingTask, err := repository.GetTaskByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务失败"})
+		return
+	}
+
+	if existingTask == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+		return
+	}
+
+	if !middleware.CheckProjectPermission(c, existingTask.ProjectID, models.PermissionEdit) {
+		return
+	}
+
+	cascade := c.Query("cascade") == "true"
+	affected, err := taskDeletionAffected(existingTask, cascade)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取子任务失败"})
+		return
+	}
+
+	deleted := 1
+	if cascade {
+		deleted += len(affected)
+	}
+
+	// 移入回收站，附件文件保留到彻底删除时
+	if err := repository.DeleteTask(existingTask.ID, cascade); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除任务失败"})
+		return
+	}
+	recordTaskDeletion(c, existingTask, affected, cascade)
+
+	c.JSON(http.StatusOK, gin.H{"message": "任务已移入回收站", "deleted": deleted})
+}
+
+// taskDeletionAffected 收集删除任务时受影响的任务：级联删除时为全部下级任务，否则为上移一级的直接子任务
+func taskDeletionAffected(task *models.Task, cascade bool) ([]models.Task, error) {
+	if !cascade {
+		return repository.GetTaskChildren(task.ID)
+	}
+	descendantIDs, err := repository.GetTaskDescendantIDs(task.ID)
+	if err != nil {
+		return nil, err
+	}
+	return repository.GetTasksByIDs(descendantIDs)
+}
+
+// recordTaskDeletion 记录删除任务及受影响任务的操作记录
+func recordTaskDeletion(c *gin.Context, task *models.Task, affected []models.Task, cascade bool) {
+	recordActivity(c, task.ProjectID, models.EntityTask, task.ID, models.TaskSnapshot(task), nil)
+	for i := range affected {
+		child := &affected[i]
+		before := models.TaskSnapshot(child)
+		if cascade {
+			recordActivity(c, child.ProjectID, models.EntityTask, child.ID, before, nil)
+		} else {
+			child.ParentID = task.ParentID
+			recordActivity(c, child.ProjectID, models.EntityTask, child.ID, before, models.TaskSnapshot(child))
+		}
+	}
+}
+
+// UpdateTaskMilestone 将任务移动到其他里程碑或移出里程碑
+func UpdateTaskMilestone(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+
+	existingTask, err := repository.GetTaskByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务失败"})
+		return
+	}
+
+	if existingTask == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+		return
+	}
+
+	if !middleware.CheckProjectPermission(c, existingTask.ProjectID, models.PermissionEdit) {
+		return
+	}
+
+	before := models.TaskSnapshot(existingTask)
+
+	var req TaskMilestoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	if !checkVersion(c, req.Version, existingTask.Version, existingTask, false) {
+		return
+	}
+	if !checkTaskMilestone(c, existingTask.ProjectID, req.MilestoneID) {
+		return
+	}
+
+	existingTask.MilestoneID = req.MilestoneID
 	if err := repository.UpdateTask(existingTask); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新任务失败"})
+		writeTaskSaveError(c, existingTask.ID, err)
 		return
 	}
+	recordActivity(c, existingTask.ProjectID, models.EntityTask, existingTask.ID, before, models.TaskSnapshot(existingTask))
 
+	setETag(c, existingTask.Version)
 	c.JSON(http.StatusOK, existingTask)
 }
 
-// DeleteTask 删除任务
-func DeleteTask(c *gin.Context) {
+// GetTaskSubtasks 获取任务的直接子任务，支持与任务列表相同的筛选、排序和分页参数
+func GetTaskSubtasks(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+
+	task, err := repository.GetTaskByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务失败"})
+		return
+	}
+
+	if task == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "任务不存在"})
+		return
+	}
+
+	if !middleware.CheckProjectPermission(c, task.ProjectID, models.PermissionView) {
+		return
+	}
+
+	listTasks(c, repository.TaskFilter{ProjectIDs: []uint{task.ProjectID}, ParentID: &task.ID})
+}
+
+// UpdateTaskParent 将任务连同其全部下级任务移动到其他父任务下或移动为顶层任务
+func UpdateTaskParent(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -153,7 +532,6 @@ func DeleteTask(c *gin.Context) {
 		return
 	}
 
-	// 检查任务是否存在
 	existingTask, err := repository.GetTaskByID(uint(id))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务失败"})
@@ -165,11 +543,266 @@ func DeleteTask(c *gin.Context) {
 		return
 	}
 
-	// 删除任务
-	if err := repository.DeleteTask(uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除任务失败"})
+	if !middleware.CheckProjectPermission(c, existingTask.ProjectID, models.PermissionEdit) {
+		return
+	}
+	before := models.TaskSnapshot(existingTask)
+
+	var req TaskParentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	if !checkVersion(c, req.Version, existingTask.Version, existingTask, false) {
+		return
+	}
+	if !checkTaskParent(c, existingTask.ProjectID, existingTask.ID, req.ParentID) {
+		return
+	}
+
+	existingTask.ParentID = req.ParentID
+	existingTask.TrashedParentID = nil // 手动移动后，原父任务恢复时不再移回
+	if err := repository.UpdateTask(existingTask); err != nil {
+		writeTaskSaveError(c, existingTask.ID, err)
 		return
 	}
+	recordActivity(c, existingTask.ProjectID, models.EntityTask, existingTask.ID, before, models.TaskSnapshot(existingTask))
+
+	setETag(c, existingTask.Version)
+	c.JSON(http.StatusOK, existingTask)
+}
+
+// checkTaskParent 检查父任务是否存在、属于同一项目且不会形成环，taskID为0表示新建任务，失败时已写入响应
+func checkTaskParent(c *gin.Context, projectID, taskID uint, parentID *uint) bool {
+	if parentID == nil {
+		return true
+	}
+
+	parent, err := repository.GetTaskByID(*parentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取父任务失败"})
+		return false
+	}
+
+	if parent == nil || parent.ProjectID != projectID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "父任务不存在或不属于该项目"})
+		return false
+	}
+
+	if taskID == 0 {
+		return true
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "任务已删除"})
-} 
\ No newline at end of file
+	// 不能移动到自身或自己的下级任务下
+	descendants, err := repository.GetTaskDescendantIDs(taskID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取子任务失败"})
+		return false
+	}
+	for _, id := range append(descendants, taskID) {
+		if id == parent.ID {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "不能将任务移动到自身或其子任务下", "code": "parent_cycle"})
+			return false
+		}
+	}
+
+	return true
+}
+
+// checkTaskNotInHierarchy 检查任务既没有父任务也没有子任务，失败时已写入响应
+func checkTaskNotInHierarchy(c *gin.Context, task *models.Task) bool {
+	children, err := repository.CountTaskChildren(task.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取子任务失败"})
+		return false
+	}
+
+	if task.ParentID != nil || children > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "存在父任务或子任务的任务不能更换项目", "code": "task_in_hierarchy"})
+		return false
+	}
+
+	return true
+}
+
+// checkTaskHasNoDependencies 检查任务没有任何依赖关系，依赖只能在同一项目内建立，失败时已写入响应
+func checkTaskHasNoDependencies(c *gin.Context, task *models.Task) bool {
+	count, err := repository.CountTaskDependencies(task.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务依赖失败"})
+		return false
+	}
+
+	if count > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "存在依赖关系的任务不能更换项目", "code": "task_has_dependencies"})
+		return false
+	}
+
+	return true
+}
+
+// attachTaskRollups 为任务列表填充子任务汇总
+func attachTaskRollups(tasks []models.Task) error {
+	ids := make([]uint, len(tasks))
+	for i := range tasks {
+		ids[i] = tasks[i].ID
+	}
+
+	rollups, err := repository.GetTaskRollups(ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range tasks {
+		tasks[i].Rollup = rollups[tasks[i].ID]
+	}
+	return nil
+}
+
+// checkTaskMilestone 检查任务关联的里程碑是否存在且属于同一项目，失败时已写入响应
+func checkTaskMilestone(c *gin.Context, projectID uint, milestoneID *uint) bool {
+	if milestoneID == nil {
+		return true
+	}
+
+	milestone, err := repository.GetMilestoneByID(*milestoneID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取里程碑失败"})
+		return false
+	}
+
+	if milestone == nil || milestone.ProjectID != projectID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "里程碑不存在或不属于该项目"})
+		return false
+	}
+
+	return true
+}
+
+// GetWorkflow 获取当前生效的任务状态流转规则
+func GetWorkflow(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"statuses":    models.TaskStatuses,
+		"transitions": workflow.Current().Transitions(),
+	})
+}
+
+// checkTaskUrgency 检查紧急程度是否合法，失败时已写入响应
+func checkTaskUrgency(c *gin.Context, urgency models.TaskUrgency) bool {
+	if !urgency.IsValid() {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "无效的紧急程度",
+			"code":    "invalid_urgency",
+			"allowed": models.TaskUrgencies,
+		})
+		return false
+	}
+	return true
+}
+
+// parseStartDate 解析计划开始日期，为空时返回nil，开始日期不能晚于截止日期，失败时已写入响应
+func parseStartDate(c *gin.Context, value string, deadline time.Time) (*time.Time, bool) {
+	if value == "" {
+		return nil, true
+	}
+
+	startDate, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的日期格式"})
+		return nil, false
+	}
+
+	if startDate.After(deadline) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "开始日期不能晚于截止日期", "code": "invalid_start_date"})
+		return nil, false
+	}
+
+	return &startDate, true
+}
+
+// maxEstimatedDays 任务预计工期的上限
+const maxEstimatedDays = 3650
+
+// checkEstimatedDays 检查预计工期是否在允许范围内，失败时已写入响应
+func checkEstimatedDays(c *gin.Context, days int) bool {
+	if days < 1 || days > maxEstimatedDays {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": fmt.Sprintf("预计工期必须在1到%d天之间", maxEstimatedDays),
+			"code":  "invalid_estimated_days",
+		})
+		return false
+	}
+	return true
+}
+
+// writeWorkflowError 将状态机返回的错误写入响应
+func writeWorkflowError(c *gin.Context, err error, from, to models.TaskStatus) {
+	switch {
+	case errors.Is(err, workflow.ErrUnknownStatus):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "无效的任务状态",
+			"code":    "invalid_status",
+			"allowed": models.TaskStatuses,
+		})
+	case errors.Is(err, workflow.ErrInvalidTransition):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "不允许从「" + string(from) + "」变更为「" + string(to) + "」",
+			"code":    "invalid_transition",
+			"from":    from,
+			"to":      to,
+			"allowed": workflow.Current().AllowedTransitions(from),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新任务状态失败"})
+	}
+}
+
+// GetMyTasks 获取分配给当前用户的任务，支持与任务列表相同的筛选、排序和分页参数
+func GetMyTasks(c *gin.Context) {
+	userID := c.GetUint("userID")
+	projectIDs, err := repository.GetUserProjectIDs(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务失败"})
+		return
+	}
+
+	listTasks(c, repository.TaskFilter{ProjectIDs: projectIDs, AssigneeID: &userID})
+}
+
+// resolveAssignees 根据用户ID加载任务负责人，负责人必须是项目成员，失败时已写入响应
+func resolveAssignees(c *gin.Context, projectID uint, userIDs []uint) ([]models.User, bool) {
+	// 去除重复的用户ID
+	seen := make(map[uint]bool, len(userIDs))
+	ids := make([]uint, 0, len(userIDs))
+	for _, id := range userIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	users, err := repository.GetUsersByIDs(ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取用户信息失败"})
+		return nil, false
+	}
+
+	if len(users) != len(ids) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "负责人不存在"})
+		return nil, false
+	}
+
+	members, err := repository.CountProjectMembersIn(projectID, ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目成员失败"})
+		return nil, false
+	}
+
+	if members != int64(len(ids)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "负责人必须是项目成员"})
+		return nil, false
+	}
+
+	return users, true
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"project_management/internal/middleware"
+	"project_management/internal/models"
+	"project_management/internal/repository"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 时间线的默认范围和最大天数
+const (
+	defaultTimelineDaysBefore = 30
+	defaultTimelineDays       = 120
+	maxTimelineDays           = 366
+)
+
+// GetTimeline 获取甘特图数据，返回与时间范围有交集的任务、范围内的里程碑以及任务之间的依赖关系。
+// 查询参数from、to指定时间范围（含），默认为30天前起的120天；project_id限定项目，默认为当前用户参与的全部项目
+func GetTimeline(c *gin.Context) {
+	var projectIDs []uint
+	if projectID := c.Query("project_id"); projectID != "" {
+		id, err := strconv.ParseUint(projectID, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+			return
+		}
+		if !middleware.CheckProjectPermission(c, uint(id), models.PermissionView) {
+			return
+		}
+		projectIDs = []uint{uint(id)}
+	} else {
+		ids, err := repository.GetUserProjectIDs(c.GetUint("userID"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取时间线失败"})
+			return
+		}
+		projectIDs = ids
+	}
+
+	from, to, ok := parseTimelineRange(c)
+	if !ok {
+		return
+	}
+
+	tasks, err := repository.GetTimelineTasks(projectIDs, from, to, to.AddDate(0, 0, maxEstimatedDays))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务失败"})
+		return
+	}
+
+	// 按推算的开始日期过滤，并收集任务关联的里程碑
+	visible := make([]models.Task, 0, len(tasks))
+	taskIDs := make([]uint, 0, len(tasks))
+	var milestoneIDs []uint
+	seen := make(map[uint]bool)
+	for _, task := range tasks {
+		if task.PlannedStart().After(to) {
+			continue
+		}
+		visible = append(visible, task)
+		taskIDs = append(taskIDs, task.ID)
+		if task.MilestoneID != nil && !seen[*task.MilestoneID] {
+			seen[*task.MilestoneID] = true
+			milestoneIDs = append(milestoneIDs, *task.MilestoneID)
+		}
+	}
+
+	milestones, err := repository.GetTimelineMilestones(projectIDs, from, to, milestoneIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取里程碑失败"})
+		return
+	}
+
+	dependencies, err := repository.GetDependenciesAmong(taskIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务依赖失败"})
+		return
+	}
+
+	projects, err := repository.GetProjectsByIDs(projectIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.Timeline{
+		From:         from,
+		To:           to,
+		Projects:     buildTimelineProjects(projects, visible, milestones),
+		Dependencies: dependencies,
+	})
+}
+
+// parseTimelineRange 解析时间线的日期范围，失败时已写入响应
+func parseTimelineRange(c *gin.Context) (time.Time, time.Time, bool) {
+	fromParam, ok := queryDate(c, "from")
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	toParam, ok := queryDate(c, "to")
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+
+	today := models.OverdueCutoff()
+	from := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -defaultTimelineDaysBefore)
+	if fromParam != nil {
+		from = *fromParam
+	}
+	to := from.AddDate(0, 0, defaultTimelineDays-1)
+	if toParam != nil {
+		to = *toParam
+		if fromParam == nil {
+			from = to.AddDate(0, 0, 1-defaultTimelineDays)
+		}
+	}
+
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "结束日期不能早于开始日期"})
+		return time.Time{}, time.Time{}, false
+	}
+	if to.Sub(from) >= maxTimelineDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("时间范围不能超过%d天", maxTimelineDays)})
+		return time.Time{}, time.Time{}, false
+	}
+
+	return from, to, true
+}
+
+// buildTimelineProjects 将任务按项目和里程碑分组。组内按任务树排列，父任务不在同一组时视为顶层任务，
+// 同级任务按开始日期、截止日期和ID排序。没有任务的项目和范围内的里程碑也会返回，便于绘制空行和里程碑标记
+func buildTimelineProjects(projects []models.Project, tasks []models.Task, milestones []models.Milestone) []models.TimelineProject {
+	type groupKey struct {
+		projectID   uint
+		milestoneID uint // 0 表示未关联里程碑
+	}
+	grouped := make(map[groupKey][]models.Task)
+	for _, task := range tasks {
+		key := groupKey{projectID: task.ProjectID}
+		if task.MilestoneID != nil {
+			key.milestoneID = *task.MilestoneID
+		}
+		grouped[key] = append(grouped[key], task)
+	}
+
+	result := make([]models.TimelineProject, 0, len(projects))
+	for _, project := range projects {
+		groups := []models.TimelineGroup{}
+		for i := range milestones {
+			milestone := milestones[i]
+			if milestone.ProjectID != project.ID {
+				continue
+			}
+			groups = append(groups, models.TimelineGroup{
+				Milestone: &milestone,
+				Tasks:     timelineTaskTree(grouped[groupKey{project.ID, milestone.ID}]),
+			})
+		}
+		if ungrouped := grouped[groupKey{projectID: project.ID}]; len(ungrouped) > 0 {
+			groups = append(groups, models.TimelineGroup{Tasks: timelineTaskTree(ungrouped)})
+		}
+
+		result = append(result, models.TimelineProject{ProjectID: project.ID, Name: project.Name, Groups: groups})
+	}
+	return result
+}
+
+// timelineTaskTree 按父子关系深度优先排列任务，并记录每个任务的层级
+func timelineTaskTree(tasks []models.Task) []models.TimelineTask {
+	sort.Slice(tasks, func(i, j int) bool {
+		si, sj := tasks[i].PlannedStart(), tasks[j].PlannedStart()
+		if !si.Equal(sj) {
+			return si.Before(sj)
+		}
+		if !tasks[i].Deadline.Equal(tasks[j].Deadline) {
+			return tasks[i].Deadline.Before(tasks[j].Deadline)
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+
+	inGroup := make(map[uint]bool, len(tasks))
+	for _, task := range tasks {
+		inGroup[task.ID] = true
+	}
+	children := make(map[uint][]int)
+	var roots []int
+	for i, task := range tasks {
+		if task.ParentID != nil && inGroup[*task.ParentID] {
+			children[*task.ParentID] = append(children[*task.ParentID], i)
+		} else {
+			roots = append(roots, i)
+		}
+	}
+
+	result := make([]models.TimelineTask, 0, len(tasks))
+	var visit func(i, depth int)
+	visit = func(i, depth int) {
+		task := tasks[i]
+		result = append(result, models.TimelineTask{
+			ID:             task.ID,
+			ParentID:       task.ParentID,
+			Depth:          depth,
+			Name:           task.Name,
+			Status:         task.Status,
+			Urgency:        task.Urgency,
+			Start:          task.PlannedStart(),
+			End:            task.Deadline,
+			StartEstimated: task.StartDate == nil,
+			Assignees:      task.Assignees,
+		})
+		for _, child := range children[task.ID] {
+			visit(child, depth+1)
+		}
+	}
+	for _, root := range roots {
+		visit(root, 0)
+	}
+	return result
+}
@@ -0,0 +1,766 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"project_management/internal/middleware"
+	"project_management/internal/models"
+	"project_management/internal/repository"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 导入导出支持的格式
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// 导入数据的限制
+const (
+	maxImportRows  = 1000
+	maxImportBytes = 5 << 20
+)
+
+// utf8BOM 写在导出的CSV开头，便于Excel正确识别中文
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// 以这些字符开头的CSV单元格会被Excel等表格软件当作公式，导出时在前面加单引号
+const formulaPrefixes = "=+-@\t\r"
+
+// CSV中多个负责人用户名之间的分隔符
+const assigneeSeparator = ";"
+
+// 任务导出的列，与TaskRecord的JSON字段一致
+var taskColumns = []string{"id", "project_id", "milestone_id", "parent_id", "name", "status", "urgency", "start_date", "deadline", "estimated_days", "assignees"}
+
+// 里程碑导出的列，与MilestoneRecord的JSON字段一致
+var milestoneColumns = []string{"id", "project_id", "title", "date", "description"}
+
+// TaskRecord 任务导入导出的数据格式。导入时忽略project_id，任务均创建在指定的项目中；
+// id只用于在导入数据中查找parent_id对应的父任务
+type TaskRecord struct {
+	ID            uint               `json:"id"`
+	ProjectID     uint               `json:"project_id"`
+	MilestoneID   *uint              `json:"milestone_id"`
+	ParentID      *uint              `json:"parent_id"`
+	Name          string             `json:"name"`
+	Status        models.TaskStatus  `json:"status"`
+	Urgency       models.TaskUrgency `json:"urgency"`
+	StartDate     string             `json:"start_date"`
+	Deadline      string             `json:"deadline"`
+	EstimatedDays int                `json:"estimated_days"`
+	Assignees     []string           `json:"assignees"` // 负责人用户名
+}
+
+// MilestoneRecord 里程碑导入导出的数据格式。导入时忽略id和project_id，里程碑均创建在指定的项目中
+type MilestoneRecord struct {
+	ID          uint   `json:"id"`
+	ProjectID   uint   `json:"project_id"`
+	Title       string `json:"title"`
+	Date        string `json:"date"`
+	Description string `json:"description"`
+}
+
+// ImportRowWarning 导入数据中某一行被忽略的字段，不影响导入
+type ImportRowWarning struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field"`
+	Warning string `json:"warning"`
+	Code    string `json:"code"`
+}
+
+// ImportRowError 导入数据中某一行的错误
+type ImportRowError struct {
+	Row     int                    `json:"row"` // CSV中为行号（表头为第1行），JSON中为数组下标（从1开始）
+	Status  int                    `json:"status"`
+	Error   string                 `json:"error"`
+	Code    string                 `json:"code,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// importRow 导入数据中的一行
+type importRow struct {
+	number int               // 行号
+	fields map[string]string // CSV格式下以表头为键的列值
+	raw    json.RawMessage   // JSON格式下的数组元素
+}
+
+// ExportTasks 导出当前用户参与项目中的任务，支持与任务列表相同的筛选参数，format为csv或json（默认）
+func ExportTasks(c *gin.Context) {
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+	projectIDs, ok := queryProjectScope(c, "获取任务失败")
+	if !ok {
+		return
+	}
+	filter, ok := parseTaskFilter(c, repository.TaskFilter{ProjectIDs: projectIDs})
+	if !ok {
+		return
+	}
+
+	tasks, err := repository.ExportTasks(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务失败"})
+		return
+	}
+
+	records := make([]TaskRecord, len(tasks))
+	for i, task := range tasks {
+		records[i] = taskRecordOf(&task)
+	}
+
+	if format == FormatJSON {
+		writeExportJSON(c, "tasks", records)
+		return
+	}
+	rows := make([][]string, len(records))
+	for i, record := range records {
+		rows[i] = taskRecordFields(record)
+	}
+	writeExportCSV(c, "tasks", taskColumns, rows)
+}
+
+// ExportMilestones 导出当前用户参与项目中的里程碑，支持与里程碑列表相同的筛选参数，format为csv或json（默认）
+func ExportMilestones(c *gin.Context) {
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+	projectIDs, ok := queryProjectScope(c, "获取里程碑失败")
+	if !ok {
+		return
+	}
+	filter, ok := parseMilestoneFilter(c, repository.MilestoneFilter{ProjectIDs: projectIDs})
+	if !ok {
+		return
+	}
+
+	milestones, err := repository.ExportMilestones(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取里程碑失败"})
+		return
+	}
+
+	records := make([]MilestoneRecord, len(milestones))
+	for i, milestone := range milestones {
+		records[i] = MilestoneRecord{
+			ID:          milestone.ID,
+			ProjectID:   milestone.ProjectID,
+			Title:       milestone.Title,
+			Date:        milestone.Date.Format("2006-01-02"),
+			Description: milestone.Description,
+		}
+	}
+
+	if format == FormatJSON {
+		writeExportJSON(c, "milestones", records)
+		return
+	}
+	rows := make([][]string, len(records))
+	for i, record := range records {
+		rows[i] = []string{
+			strconv.FormatUint(uint64(record.ID), 10),
+			strconv.FormatUint(uint64(record.ProjectID), 10),
+			record.Title,
+			record.Date,
+			record.Description,
+		}
+	}
+	writeExportCSV(c, "milestones", milestoneColumns, rows)
+}
+
+// importedTask 通过校验、等待导入的任务
+type importedTask struct {
+	row      int
+	id       uint  // 导入数据中的任务ID
+	parentID *uint // 导入数据中的父任务ID
+	task     *models.Task
+}
+
+// ImportTasks 将CSV或JSON数据导入为project_id指定项目中的任务。每行使用与创建任务相同的校验规则，
+// 任一行有误时不导入任何数据并返回422及每行的错误；dry_run=true时只校验不导入。
+// parent_id按导入数据中的id匹配父任务，不属于目标项目的里程碑和找不到的父任务会被清空并返回警告
+func ImportTasks(c *gin.Context) {
+	projectID, ok := importProject(c)
+	if !ok {
+		return
+	}
+	rows, ok := readImportRows(c, []string{"name", "deadline"})
+	if !ok {
+		return
+	}
+
+	imported := make([]importedTask, 0, len(rows))
+	var rowErrors []ImportRowError
+	var warnings []ImportRowWarning
+	for _, row := range rows {
+		var record TaskRecord
+		var task *models.Task
+		failure := captureResponse(c, func() bool {
+			var err error
+			record, err = decodeTaskRecord(row)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return false
+			}
+			cleared, ok := clearForeignMilestone(c, projectID, &record)
+			if !ok {
+				return false
+			}
+			if cleared {
+				warnings = append(warnings, ImportRowWarning{
+					Row:     row.number,
+					Field:   "milestone_id",
+					Warning: "里程碑不存在或不属于该项目，已清空",
+					Code:    "milestone_cleared",
+				})
+			}
+			var valid bool
+			task, valid = prepareTaskImport(c, projectID, &record)
+			return valid
+		})
+		if failure != nil {
+			rowErrors = append(rowErrors, importRowError(row.number, failure))
+			continue
+		}
+		imported = append(imported, importedTask{row: row.number, id: record.ID, parentID: record.ParentID, task: task})
+	}
+
+	tasks, parents, parentWarnings := resolveImportParents(imported)
+	warnings = append(warnings, parentWarnings...)
+
+	if !writeImportValidation(c, len(rows), rowErrors, warnings) {
+		return
+	}
+
+	if err := repository.CreateTasks(tasks, parents); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "导入任务失败"})
+		return
+	}
+	for _, task := range tasks {
+		recordActivity(c, task.ProjectID, models.EntityTask, task.ID, nil, models.TaskSnapshot(task))
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"dry_run": false, "total": len(rows), "valid": len(tasks), "created": len(tasks), "errors": []ImportRowError{}, "warnings": warnings})
+}
+
+// ImportMilestones 将CSV或JSON数据导入为project_id指定项目中的里程碑。每行使用与创建里程碑相同的校验规则，
+// 任一行有误时不导入任何数据并返回422及每行的错误；dry_run=true时只校验不导入
+func ImportMilestones(c *gin.Context) {
+	projectID, ok := importProject(c)
+	if !ok {
+		return
+	}
+	rows, ok := readImportRows(c, []string{"title", "date"})
+	if !ok {
+		return
+	}
+
+	milestones := make([]*models.Milestone, 0, len(rows))
+	var rowErrors []ImportRowError
+	for _, row := range rows {
+		var milestone *models.Milestone
+		failure := captureResponse(c, func() bool {
+			record, err := decodeMilestoneRecord(row)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return false
+			}
+			if strings.TrimSpace(record.Title) == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "标题不能为空"})
+				return false
+			}
+
+			var valid bool
+			req := MilestoneRequest{ProjectID: projectID, Title: record.Title, Date: record.Date, Description: record.Description}
+			milestone, valid = prepareMilestoneCreate(c, &req)
+			return valid
+		})
+		if failure != nil {
+			rowErrors = append(rowErrors, importRowError(row.number, failure))
+			continue
+		}
+		milestones = append(milestones, milestone)
+	}
+
+	if !writeImportValidation(c, len(rows), rowErrors, nil) {
+		return
+	}
+
+	if err := repository.CreateMilestones(milestones); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "导入里程碑失败"})
+		return
+	}
+	for _, milestone := range milestones {
+		recordActivity(c, milestone.ProjectID, models.EntityMilestone, milestone.ID, nil, models.MilestoneSnapshot(milestone))
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"dry_run": false, "total": len(rows), "valid": len(milestones), "created": len(milestones), "errors": []ImportRowError{}, "warnings": []ImportRowWarning{}})
+}
+
+// prepareTaskImport 按创建任务的规则校验导入的任务，负责人按用户名查找，失败时已写入响应
+func prepareTaskImport(c *gin.Context, projectID uint, record *TaskRecord) (*models.Task, bool) {
+	if strings.TrimSpace(record.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "任务名称不能为空"})
+		return nil, false
+	}
+
+	assigneeIDs := make([]uint, 0, len(record.Assignees))
+	for _, username := range record.Assignees {
+		user, err := repository.GetUserByUsername(username)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取用户信息失败"})
+			return nil, false
+		}
+		if user == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "负责人不存在: " + username})
+			return nil, false
+		}
+		assigneeIDs = append(assigneeIDs, user.ID)
+	}
+
+	req := TaskRequest{
+		ProjectID:     projectID,
+		MilestoneID:   record.MilestoneID,
+		Name:          record.Name,
+		StartDate:     record.StartDate,
+		Deadline:      record.Deadline,
+		EstimatedDays: record.EstimatedDays,
+		Status:        record.Status,
+		Urgency:       record.Urgency,
+		AssigneeIDs:   assigneeIDs,
+	}
+	return prepareTaskCreate(c, &req)
+}
+
+// clearForeignMilestone 导入的里程碑不存在或不属于目标项目（如从其他项目导出的数据）时清空并返回true，
+// 失败时已写入响应
+func clearForeignMilestone(c *gin.Context, projectID uint, record *TaskRecord) (bool, bool) {
+	if record.MilestoneID == nil {
+		return false, true
+	}
+	milestone, err := repository.GetMilestoneByID(*record.MilestoneID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取里程碑失败"})
+		return false, false
+	}
+	if milestone != nil && milestone.ProjectID == projectID {
+		return false, true
+	}
+	record.MilestoneID = nil
+	return true, true
+}
+
+// resolveImportParents 按导入数据中的id为任务匹配parent_id对应的父任务，并将任务排序为父任务在前。
+// 返回排序后的任务、每个任务的父任务在其中的下标（-1表示顶层任务），以及找不到父任务、
+// 父任务ID重复或形成循环时清空父任务的警告
+func resolveImportParents(imported []importedTask) ([]*models.Task, []int, []ImportRowWarning) {
+	// index 导入数据中的任务ID到下标的映射，ID重复时为-1
+	index := make(map[uint]int, len(imported))
+	for i, item := range imported {
+		if item.id == 0 {
+			continue
+		}
+		if _, exists := index[item.id]; exists {
+			index[item.id] = -1
+		} else {
+			index[item.id] = i
+		}
+	}
+
+	var warnings []ImportRowWarning
+	parent := make([]int, len(imported))
+	for i, item := range imported {
+		parent[i] = -1
+		if item.parentID == nil {
+			continue
+		}
+		if j, ok := index[*item.parentID]; ok && j >= 0 {
+			parent[i] = j
+			continue
+		}
+		warnings = append(warnings, ImportRowWarning{
+			Row:     item.row,
+			Field:   "parent_id",
+			Warning: "父任务不在导入数据中或ID重复，已导入为顶层任务",
+			Code:    "parent_cleared",
+		})
+	}
+
+	// 按层级排序，父任务链形成循环时在回到已访问的任务处断开
+	depth := make([]int, len(imported))
+	for i := range imported {
+		depth[i] = -1
+	}
+	var depthOf func(i int, visiting map[int]bool) int
+	depthOf = func(i int, visiting map[int]bool) int {
+		if depth[i] >= 0 {
+			return depth[i]
+		}
+		visiting[i] = true
+		switch {
+		case parent[i] < 0:
+			depth[i] = 0
+		case visiting[parent[i]]:
+			parent[i] = -1
+			depth[i] = 0
+			warnings = append(warnings, ImportRowWarning{
+				Row:     imported[i].row,
+				Field:   "parent_id",
+				Warning: "父任务形成循环，已导入为顶层任务",
+				Code:    "parent_cycle",
+			})
+		default:
+			depth[i] = depthOf(parent[i], visiting) + 1
+		}
+		return depth[i]
+	}
+	order := make([]int, len(imported))
+	for i := range imported {
+		depthOf(i, map[int]bool{})
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return depth[order[a]] < depth[order[b]] })
+
+	position := make([]int, len(imported))
+	for pos, i := range order {
+		position[i] = pos
+	}
+	tasks := make([]*models.Task, len(imported))
+	parents := make([]int, len(imported))
+	for pos, i := range order {
+		tasks[pos] = imported[i].task
+		parents[pos] = -1
+		if parent[i] >= 0 {
+			parents[pos] = position[parent[i]]
+		}
+	}
+	sort.SliceStable(warnings, func(a, b int) bool { return warnings[a].Row < warnings[b].Row })
+	return tasks, parents, warnings
+}
+
+// taskRecordOf 将任务转换为导出格式
+func taskRecordOf(task *models.Task) TaskRecord {
+	record := TaskRecord{
+		ID:            task.ID,
+		ProjectID:     task.ProjectID,
+		MilestoneID:   task.MilestoneID,
+		ParentID:      task.ParentID,
+		Name:          task.Name,
+		Status:        task.Status,
+		Urgency:       task.Urgency,
+		Deadline:      task.Deadline.Format("2006-01-02"),
+		EstimatedDays: task.EstimatedDays,
+		Assignees:     make([]string, len(task.Assignees)),
+	}
+	if task.StartDate != nil {
+		record.StartDate = task.StartDate.Format("2006-01-02")
+	}
+	for i, user := range task.Assignees {
+		record.Assignees[i] = user.Username
+	}
+	return record
+}
+
+// taskRecordFields 按taskColumns的顺序输出任务的CSV列值
+func taskRecordFields(record TaskRecord) []string {
+	optionalID := func(id *uint) string {
+		if id == nil {
+			return ""
+		}
+		return strconv.FormatUint(uint64(*id), 10)
+	}
+	return []string{
+		strconv.FormatUint(uint64(record.ID), 10),
+		strconv.FormatUint(uint64(record.ProjectID), 10),
+		optionalID(record.MilestoneID),
+		optionalID(record.ParentID),
+		record.Name,
+		string(record.Status),
+		string(record.Urgency),
+		record.StartDate,
+		record.Deadline,
+		strconv.Itoa(record.EstimatedDays),
+		strings.Join(record.Assignees, assigneeSeparator),
+	}
+}
+
+// decodeTaskRecord 解析导入数据中的一行任务
+func decodeTaskRecord(row importRow) (TaskRecord, error) {
+	var record TaskRecord
+	if row.raw != nil {
+		if err := json.Unmarshal(row.raw, &record); err != nil {
+			return record, errors.New("无效的数据格式")
+		}
+		return record, nil
+	}
+
+	record = TaskRecord{
+		Name:      row.fields["name"],
+		Status:    models.TaskStatus(row.fields["status"]),
+		Urgency:   models.TaskUrgency(row.fields["urgency"]),
+		StartDate: row.fields["start_date"],
+		Deadline:  row.fields["deadline"],
+	}
+	if value := row.fields["id"]; value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return record, errors.New("无效的任务ID")
+		}
+		record.ID = uint(id)
+	}
+	if value := row.fields["milestone_id"]; value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return record, errors.New("无效的里程碑ID")
+		}
+		milestoneID := uint(id)
+		record.MilestoneID = &milestoneID
+	}
+	if value := row.fields["parent_id"]; value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return record, errors.New("无效的父任务ID")
+		}
+		parentID := uint(id)
+		record.ParentID = &parentID
+	}
+	if value := row.fields["estimated_days"]; value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil {
+			return record, errors.New("预计工期必须是整数")
+		}
+		record.EstimatedDays = days
+	}
+	for _, username := range strings.Split(row.fields["assignees"], assigneeSeparator) {
+		if username = strings.TrimSpace(username); username != "" {
+			record.Assignees = append(record.Assignees, username)
+		}
+	}
+	return record, nil
+}
+
+// decodeMilestoneRecord 解析导入数据中的一行里程碑
+func decodeMilestoneRecord(row importRow) (MilestoneRecord, error) {
+	var record MilestoneRecord
+	if row.raw != nil {
+		if err := json.Unmarshal(row.raw, &record); err != nil {
+			return record, errors.New("无效的数据格式")
+		}
+		return record, nil
+	}
+
+	return MilestoneRecord{
+		Title:       row.fields["title"],
+		Date:        row.fields["date"],
+		Description: row.fields["description"],
+	}, nil
+}
+
+// exportFormat 读取导出格式参数，失败时已写入响应
+func exportFormat(c *gin.Context) (string, bool) {
+	format := strings.ToLower(c.DefaultQuery("format", FormatJSON))
+	if format != FormatCSV && format != FormatJSON {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的格式: " + format})
+		return "", false
+	}
+	return format, true
+}
+
+// writeExportJSON 以附件形式写入JSON格式的导出数据
+func writeExportJSON(c *gin.Context, name string, records interface{}) {
+	c.Header("Content-Disposition", exportDisposition(name, FormatJSON))
+	c.JSON(http.StatusOK, records)
+}
+
+// writeExportCSV 以附件形式写入CSV格式的导出数据，第一行为表头
+func writeExportCSV(c *gin.Context, name string, columns []string, rows [][]string) {
+	var buf bytes.Buffer
+	buf.Write(utf8BOM)
+	writer := csv.NewWriter(&buf)
+	writer.Write(columns)
+	for _, row := range rows {
+		for i, value := range row {
+			row[i] = escapeFormula(value)
+		}
+	}
+	writer.WriteAll(rows)
+	if err := writer.Error(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "导出失败"})
+		return
+	}
+
+	c.Header("Content-Disposition", exportDisposition(name, FormatCSV))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+// escapeFormula 在会被表格软件当作公式的单元格前加单引号，防止CSV公式注入
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune(formulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// unescapeFormula 去掉导出时为防止公式注入而加的单引号
+func unescapeFormula(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
+
+// exportDisposition 生成导出文件的Content-Disposition，文件名包含导出日期
+func exportDisposition(name, format string) string {
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().Format("20060102"), format)
+	return mime.FormatMediaType("attachment", map[string]string{"filename": filename})
+}
+
+// importProject 读取导入的目标项目并检查编辑权限，失败时已写入响应
+func importProject(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Query("project_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请通过project_id指定导入的项目"})
+		return 0, false
+	}
+	if !checkProjectExists(c, uint(id)) {
+		return 0, false
+	}
+	if !middleware.CheckProjectPermission(c, uint(id), models.PermissionEdit) {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// readImportRows 读取导入数据。格式由format参数指定，未指定时根据Content-Type判断，
+// CSV第一行为表头，必须包含required中的列；JSON为对象数组。失败时已写入响应
+func readImportRows(c *gin.Context, required []string) ([]importRow, bool) {
+	format := strings.ToLower(c.Query("format"))
+	if format == "" {
+		format = FormatJSON
+		if strings.Contains(c.ContentType(), "csv") {
+			format = FormatCSV
+		}
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxImportBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "读取导入数据失败"})
+		return nil, false
+	}
+	if len(body) > maxImportBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("导入数据不能超过%dMB", maxImportBytes>>20)})
+		return nil, false
+	}
+
+	var rows []importRow
+	switch format {
+	case FormatJSON:
+		var items []json.RawMessage
+		if err := json.Unmarshal(body, &items); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "导入数据必须是JSON数组"})
+			return nil, false
+		}
+		for i, item := range items {
+			rows = append(rows, importRow{number: i + 1, raw: item})
+		}
+	case FormatCSV:
+		reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(body, utf8BOM)))
+		reader.FieldsPerRecord = -1
+		records, err := reader.ReadAll()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的CSV数据: " + err.Error()})
+			return nil, false
+		}
+		if len(records) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "CSV缺少表头"})
+			return nil, false
+		}
+
+		header := make([]string, len(records[0]))
+		present := make(map[string]bool, len(header))
+		for i, column := range records[0] {
+			header[i] = strings.ToLower(strings.TrimSpace(column))
+			present[header[i]] = true
+		}
+		for _, column := range required {
+			if !present[column] {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "CSV缺少列: " + column})
+				return nil, false
+			}
+		}
+
+		for i, record := range records[1:] {
+			fields := make(map[string]string, len(header))
+			empty := true
+			for j, value := range record {
+				if j < len(header) {
+					fields[header[j]] = unescapeFormula(strings.TrimSpace(value))
+					empty = empty && fields[header[j]] == ""
+				}
+			}
+			// 跳过空行
+			if empty {
+				continue
+			}
+			rows = append(rows, importRow{number: i + 2, fields: fields})
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的格式: " + format})
+		return nil, false
+	}
+
+	if len(rows) == 0 || len(rows) > maxImportRows {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("导入数据必须包含1到%d行", maxImportRows)})
+		return nil, false
+	}
+	return rows, true
+}
+
+// writeImportValidation 处理校验结果：dry_run=true时返回校验结果，存在错误时返回422，
+// 均不导入数据并返回false；可以导入时返回true。warnings为nil时替换为空数组
+func writeImportValidation(c *gin.Context, total int, rowErrors []ImportRowError, warnings []ImportRowWarning) bool {
+	if rowErrors == nil {
+		rowErrors = []ImportRowError{}
+	}
+	if warnings == nil {
+		warnings = []ImportRowWarning{}
+	}
+	result := gin.H{"dry_run": c.Query("dry_run") == "true", "total": total, "valid": total - len(rowErrors), "created": 0, "errors": rowErrors, "warnings": warnings}
+
+	if c.Query("dry_run") == "true" {
+		c.JSON(http.StatusOK, result)
+		return false
+	}
+	if len(rowErrors) > 0 {
+		result["error"] = "导入数据有误，未导入任何数据"
+		result["code"] = "import_invalid"
+		c.JSON(http.StatusUnprocessableEntity, result)
+		return false
+	}
+	return true
+}
+
+// importRowError 将校验失败的响应转换为行错误
+func importRowError(row int, failure *capturedError) ImportRowError {
+	return ImportRowError{
+		Row:     row,
+		Status:  failure.Status,
+		Error:   failure.Error,
+		Code:    failure.Code,
+		Details: failure.Details,
+	}
+}
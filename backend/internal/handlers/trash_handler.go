@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"net/http"
+	"project_management/internal/middleware"
+	"project_management/internal/models"
+	"project_management/internal/repository"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTrash 获取当前用户参与项目的回收站，支持entity_type筛选和分页，可通过project_id限定项目
+func GetTrash(c *gin.Context) {
+	filter := repository.TrashFilter{}
+	if projectID := c.Query("project_id"); projectID != "" {
+		id, err := strconv.ParseUint(projectID, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+			return
+		}
+		if !middleware.CheckProjectPermission(c, uint(id), models.PermissionView) {
+			return
+		}
+		filter.ProjectIDs = []uint{uint(id)}
+	} else {
+		projectIDs, err := repository.GetUserProjectIDs(c.GetUint("userID"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取回收站失败"})
+			return
+		}
+		filter.ProjectIDs = projectIDs
+	}
+
+	if entityType := c.Query("entity_type"); entityType != "" {
+		filter.EntityType = models.EntityType(entityType)
+		if !filter.EntityType.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的实体类型: " + entityType})
+			return
+		}
+	}
+
+	opts, ok := parseListOptions(c)
+	if !ok {
+		return
+	}
+
+	items, total, err := repository.ListTrash(filter, opts)
+	if err != nil {
+		writeListError(c, err, "获取回收站失败")
+		return
+	}
+
+	retention := models.TrashRetention()
+	for i := range items {
+		items[i].PurgeAt = items[i].DeletedAt.Add(retention)
+	}
+
+	c.JSON(http.StatusOK, ListResponse{Items: items, Total: total, Page: opts.Page, PageSize: opts.PageSize})
+}
+
+// RestoreTask 从回收站恢复任务，同时恢复与它一起被级联删除的下级任务，并将删除时上移一级的子任务移回
+func RestoreTask(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务ID"})
+		return
+	}
+
+	task, err := repository.GetDeletedTask(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务失败"})
+		return
+	}
+
+	if task == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "回收站中不存在该任务"})
+		return
+	}
+
+	if !middleware.CheckProjectPermission(c, task.ProjectID, models.PermissionEdit) {
+		return
+	}
+
+	ids, relinked, err := repository.RestoreTask(task)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "恢复任务失败"})
+		return
+	}
+
+	restored, err := repository.GetTasksByIDs(ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取任务失败"})
+		return
+	}
+	for i := range restored {
+		recordRestore(c, restored[i].ProjectID, models.EntityTask, restored[i].ID, models.TaskSnapshot(&restored[i]))
+	}
+	for i := range relinked {
+		child := &relinked[i]
+		before := models.TaskSnapshot(child)
+		child.ParentID = &task.ID
+		recordActivity(c, child.ProjectID, models.EntityTask, child.ID, before, models.TaskSnapshot(child))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "任务已恢复", "restored": len(ids)})
+}
+
+// RestoreMilestone 从回收站恢复里程碑，同时重新关联删除时解除关联的任务
+func RestoreMilestone(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的里程碑ID"})
+		return
+	}
+
+	milestone, err := repository.GetDeletedMilestone(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取里程碑失败"})
+		return
+	}
+
+	if milestone == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "回收站中不存在该里程碑"})
+		return
+	}
+
+	if !middleware.CheckProjectPermission(c, milestone.ProjectID, models.PermissionEdit) {
+		return
+	}
+
+	if err := repository.RestoreMilestone(milestone); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "恢复里程碑失败"})
+		return
+	}
+	recordRestore(c, milestone.ProjectID, models.EntityMilestone, milestone.ID, models.MilestoneSnapshot(milestone))
+
+	c.JSON(http.StatusOK, gin.H{"message": "里程碑已恢复"})
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"project_management/internal/repository"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checkVersion 检查客户端提交的版本号是否与current一致。版本号优先取自If-Match请求头，
+// 支持"3"、W/"3"和3三种写法，"*"表示不检查版本；未提供请求头时取自请求体的version字段。
+// required为false时允许不提供版本号。失败时已写入响应：缺少版本号返回428，格式错误返回400，
+// 版本不一致返回409并附带服务端当前数据
+func checkVersion(c *gin.Context, bodyVersion *uint, current uint, currentData interface{}, required bool) bool {
+	expected := bodyVersion
+	if header := strings.TrimSpace(c.GetHeader("If-Match")); header != "" {
+		if header == "*" {
+			return true
+		}
+		value := strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+		version, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的If-Match请求头"})
+			return false
+		}
+		v := uint(version)
+		expected = &v
+	}
+
+	if expected == nil {
+		if !required {
+			return true
+		}
+		c.JSON(http.StatusPreconditionRequired, gin.H{
+			"error": "请通过If-Match请求头或version字段提供数据的版本号",
+			"code":  "version_required",
+		})
+		return false
+	}
+
+	if *expected != current {
+		writeVersionConflict(c, current, currentData)
+		return false
+	}
+	return true
+}
+
+// writeVersionConflict 返回版本冲突，并附带服务端当前数据，便于客户端合并后重试
+func writeVersionConflict(c *gin.Context, current uint, currentData interface{}) {
+	setETag(c, current)
+	c.JSON(http.StatusConflict, gin.H{
+		"error":   "数据已被其他人修改，请基于最新数据重试",
+		"code":    "version_conflict",
+		"current": currentData,
+	})
+}
+
+// writeTaskSaveError 处理保存任务时的错误，保存期间被其他请求修改时返回最新的任务
+func writeTaskSaveError(c *gin.Context, taskID uint, err error) {
+	if errors.Is(err, repository.ErrVersionConflict) {
+		if current, err := repository.GetTaskByID(taskID); err == nil && current != nil {
+			writeVersionConflict(c, current.Version, current)
+			return
+		}
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "更新任务失败"})
+}
+
+// writeMilestoneSaveError 处理保存里程碑时的错误，保存期间被其他请求修改时返回最新的里程碑
+func writeMilestoneSaveError(c *gin.Context, milestoneID uint, err error) {
+	if errors.Is(err, repository.ErrVersionConflict) {
+		if current, err := repository.GetMilestoneByID(milestoneID); err == nil && current != nil {
+			writeVersionConflict(c, current.Version, current)
+			return
+		}
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "更新里程碑失败"})
+}
+
+// setETag 以版本号作为实体标签写入ETag响应头
+func setETag(c *gin.Context, version uint) {
+	c.Header("ETag", `"`+strconv.FormatUint(uint64(version), 10)+`"`)
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCheckVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	version := func(v uint) *uint { return &v }
+
+	tests := []struct {
+		name        string
+		ifMatch     string
+		bodyVersion *uint
+		required    bool
+		wantOK      bool
+		wantStatus  int
+		wantCode    string
+	}{
+		{name: "带引号的版本号", ifMatch: `"3"`, required: true, wantOK: true},
+		{name: "弱实体标签", ifMatch: `W/"3"`, required: true, wantOK: true},
+		{name: "不带引号的版本号", ifMatch: "3", required: true, wantOK: true},
+		{name: "星号不检查版本", ifMatch: "*", bodyVersion: version(1), required: true, wantOK: true},
+		{name: "请求头优先于请求体", ifMatch: `"3"`, bodyVersion: version(1), required: true, wantOK: true},
+		{name: "请求体中的版本号", bodyVersion: version(3), required: true, wantOK: true},
+		{name: "可选时允许不提供", required: false, wantOK: true},
+		{name: "必填时缺少版本号", required: true, wantStatus: http.StatusPreconditionRequired, wantCode: "version_required"},
+		{name: "无效的请求头", ifMatch: `"abc"`, required: false, wantStatus: http.StatusBadRequest},
+		{name: "负数版本号", ifMatch: "-1", required: true, wantStatus: http.StatusBadRequest},
+		{name: "请求头版本不一致", ifMatch: `W/"2"`, required: true, wantStatus: http.StatusConflict, wantCode: "version_conflict"},
+		{name: "请求体版本不一致", bodyVersion: version(4), required: false, wantStatus: http.StatusConflict, wantCode: "version_conflict"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPut, "/api/tasks/1", nil)
+			if tt.ifMatch != "" {
+				c.Request.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			current := gin.H{"id": 1, "version": 3}
+			if ok := checkVersion(c, tt.bodyVersion, 3, current, tt.required); ok != tt.wantOK {
+				t.Fatalf("checkVersion() = %v, want %v (response %d %s)", ok, tt.wantOK, w.Code, w.Body)
+			}
+			if tt.wantOK {
+				if w.Body.Len() != 0 {
+					t.Errorf("response written on success: %s", w.Body)
+				}
+				return
+			}
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			var body struct {
+				Code    string `json:"code"`
+				Current gin.H  `json:"current"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if body.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", body.Code, tt.wantCode)
+			}
+			if tt.wantStatus == http.StatusConflict {
+				if got := w.Header().Get("ETag"); got != `"3"` {
+					t.Errorf("ETag = %s, want \"3\"", got)
+				}
+				if body.Current["version"] != float64(3) {
+					t.Errorf("current = %v, want current data", body.Current)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"project_management/internal/models"
+	"project_management/internal/repository"
+	"project_management/internal/webhook"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 自定义签名密钥的长度范围
+const (
+	minWebhookSecretLength = 16
+	maxWebhookSecretLength = 100
+)
+
+// Webhook请求结构，secret为空时创建会自动生成、修改会保留原密钥
+type WebhookRequest struct {
+	URL    string                `json:"url" binding:"required"`
+	Secret string                `json:"secret"`
+	Events []models.WebhookEvent `json:"events" binding:"required"`
+	Active *bool                 `json:"active"` // 默认为true
+}
+
+// WebhookWithSecret 包含签名密钥的Webhook，只在创建和修改密钥时返回
+type WebhookWithSecret struct {
+	models.Webhook
+	Secret string `json:"secret"`
+}
+
+// GetProjectWebhooks 获取项目的Webhook列表
+func GetProjectWebhooks(c *gin.Context) {
+	project, ok := loadProject(c)
+	if !ok {
+		return
+	}
+
+	hooks, err := repository.GetProjectWebhooks(project.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取Webhook失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, hooks)
+}
+
+// GetProjectWebhook 获取项目的Webhook
+func GetProjectWebhook(c *gin.Context) {
+	hook, ok := loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, hook)
+}
+
+// CreateProjectWebhook 创建Webhook，响应中包含签名密钥
+func CreateProjectWebhook(c *gin.Context) {
+	project, ok := loadProject(c)
+	if !ok {
+		return
+	}
+
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	hook := &models.Webhook{ProjectID: project.ID, CreatorID: c.GetUint("userID")}
+	if !applyWebhookRequest(c, hook, &req) {
+		return
+	}
+	if hook.Secret == "" {
+		secret, err := webhook.NewSecret()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "生成签名密钥失败"})
+			return
+		}
+		hook.Secret = secret
+	}
+
+	if err := repository.CreateWebhook(hook); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建Webhook失败"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, WebhookWithSecret{Webhook: *hook, Secret: hook.Secret})
+}
+
+// UpdateProjectWebhook 修改Webhook，传入secret时更换签名密钥并在响应中返回
+func UpdateProjectWebhook(c *gin.Context) {
+	hook, ok := loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求数据"})
+		return
+	}
+
+	if !applyWebhookRequest(c, hook, &req) {
+		return
+	}
+
+	if err := repository.UpdateWebhook(hook); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "修改Webhook失败"})
+		return
+	}
+
+	if req.Secret != "" {
+		c.JSON(http.StatusOK, WebhookWithSecret{Webhook: *hook, Secret: hook.Secret})
+		return
+	}
+	c.JSON(http.StatusOK, hook)
+}
+
+// DeleteProjectWebhook 删除Webhook及其投递记录，尚未投递的通知不再发送
+func DeleteProjectWebhook(c *gin.Context) {
+	hook, ok := loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	if err := repository.DeleteWebhook(hook.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除Webhook失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook已删除"})
+}
+
+// PingProjectWebhook 向Webhook发送一条测试通知，返回加入队列的投递记录
+func PingProjectWebhook(c *gin.Context) {
+	hook, ok := loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := webhook.Ping(hook)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建测试通知失败"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// GetWebhookDeliveries 分页获取Webhook的投递记录，支持status、event筛选（逗号分隔或重复传入）
+func GetWebhookDeliveries(c *gin.Context) {
+	hook, ok := loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	filter := repository.WebhookDeliveryFilter{WebhookID: hook.ID}
+	for _, s := range queryList(c, "status") {
+		status := models.WebhookDeliveryStatus(s)
+		if status != models.DeliveryPending && status != models.DeliverySucceeded && status != models.DeliveryFailed {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的投递状态: " + s})
+			return
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+	for _, e := range queryList(c, "event") {
+		event := models.WebhookEvent(e)
+		if !event.IsValid() && event != models.WebhookPing {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的事件类型: " + e})
+			return
+		}
+		filter.Events = append(filter.Events, event)
+	}
+
+	opts, ok := parseListOptions(c)
+	if !ok {
+		return
+	}
+
+	deliveries, total, err := repository.ListWebhookDeliveries(filter, opts)
+	if err != nil {
+		writeListError(c, err, "获取投递记录失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, ListResponse{Items: deliveries, Total: total, Page: opts.Page, PageSize: opts.PageSize})
+}
+
+// GetWebhookDelivery 获取单条投递记录
+func GetWebhookDelivery(c *gin.Context) {
+	hook, ok := loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	delivery, ok := loadWebhookDelivery(c, hook.ID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// RedeliverWebhookDelivery 重新投递，投递记录重新进入队列并从第一次尝试开始计算重试
+func RedeliverWebhookDelivery(c *gin.Context) {
+	hook, ok := loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	delivery, ok := loadWebhookDelivery(c, hook.ID)
+	if !ok {
+		return
+	}
+
+	if err := webhook.Redeliver(delivery); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "重新投递失败"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// applyWebhookRequest 校验请求并写入Webhook，失败时已写入响应
+func applyWebhookRequest(c *gin.Context, hook *models.Webhook, req *WebhookRequest) bool {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "URL必须是http或https地址"})
+		return false
+	}
+	if len(req.URL) > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "URL不能超过500个字符"})
+		return false
+	}
+	if err := webhook.CheckHost(u.Hostname()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	if req.Secret != "" && (len(req.Secret) < minWebhookSecretLength || len(req.Secret) > maxWebhookSecretLength) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "签名密钥长度必须在16到100个字符之间"})
+		return false
+	}
+
+	if len(req.Events) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请至少订阅一个事件"})
+		return false
+	}
+	events := models.WebhookEventList{}
+	for _, event := range req.Events {
+		if !event.IsValid() {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":   "无效的事件类型: " + string(event),
+				"code":    "invalid_event",
+				"allowed": models.WebhookEvents,
+			})
+			return false
+		}
+		if !events.Has(event) {
+			events = append(events, event)
+		}
+	}
+
+	hook.URL = req.URL
+	hook.Events = events
+	hook.Active = req.Active == nil || *req.Active
+	if req.Secret != "" {
+		hook.Secret = req.Secret
+	}
+	return true
+}
+
+// loadWebhook 解析路径中的项目ID和Webhook ID并加载Webhook，失败时已写入响应
+func loadWebhook(c *gin.Context) (*models.Webhook, bool) {
+	projectID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+		return nil, false
+	}
+	id, err := strconv.ParseUint(c.Param("webhookId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的Webhook ID"})
+		return nil, false
+	}
+
+	hook, err := repository.GetProjectWebhook(uint(projectID), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取Webhook失败"})
+		return nil, false
+	}
+
+	if hook == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook不存在"})
+		return nil, false
+	}
+
+	return hook, true
+}
+
+// loadWebhookDelivery 解析路径中的投递记录ID并加载投递记录，失败时已写入响应
+func loadWebhookDelivery(c *gin.Context, webhookID uint) (*models.WebhookDelivery, bool) {
+	id, err := strconv.ParseUint(c.Param("deliveryId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的投递记录ID"})
+		return nil, false
+	}
+
+	delivery, err := repository.GetWebhookDelivery(webhookID, uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取投递记录失败"})
+		return nil, false
+	}
+
+	if delivery == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "投递记录不存在"})
+		return nil, false
+	}
+
+	return delivery, true
+}
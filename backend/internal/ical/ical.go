@@ -0,0 +1,72 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 每行最多75个字节，超出时折行
+const maxLineOctets = 75
+
+// textEscaper 转义TEXT类型属性值中的特殊字符
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// Builder 逐行构造iCalendar（RFC 5545）数据，行以CRLF结尾，过长的行自动折行
+type Builder struct {
+	buf bytes.Buffer
+}
+
+// Begin 开始一个组件，如VCALENDAR、VEVENT、VTODO
+func (b *Builder) Begin(component string) {
+	b.line("BEGIN:" + component)
+}
+
+// End 结束一个组件
+func (b *Builder) End(component string) {
+	b.line("END:" + component)
+}
+
+// Raw 写入不需要转义的属性，name可以带参数，如"REFRESH-INTERVAL;VALUE=DURATION"
+func (b *Builder) Raw(name, value string) {
+	b.line(name + ":" + value)
+}
+
+// Text 写入TEXT类型的属性，值中的特殊字符会被转义
+func (b *Builder) Text(name, value string) {
+	b.line(name + ":" + textEscaper.Replace(value))
+}
+
+// Date 写入DATE类型的属性，只保留t所在的日期
+func (b *Builder) Date(name string, t time.Time) {
+	b.line(name + ";VALUE=DATE:" + t.Format("20060102"))
+}
+
+// Time 写入UTC时间的DATE-TIME类型属性
+func (b *Builder) Time(name string, t time.Time) {
+	b.line(name + ":" + t.UTC().Format("20060102T150405Z"))
+}
+
+// Bytes 返回已写入的数据
+func (b *Builder) Bytes() []byte {
+	return b.buf.Bytes()
+}
+
+// line 写入一行，超过75个字节时在字符边界处折行，续行以空格开头
+func (b *Builder) line(s string) {
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.buf.WriteString(s[:cut])
+		b.buf.WriteString("\r\n ")
+		s = s[cut:]
+		// 续行开头的空格占用一个字节
+		limit = maxLineOctets - 1
+	}
+	b.buf.WriteString(s)
+	b.buf.WriteString("\r\n")
+}
@@ -0,0 +1,88 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestTextEscaping(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"普通文本", "SUMMARY:普通文本\r\n"},
+		{"a;b,c", `SUMMARY:a\;b\,c` + "\r\n"},
+		{`C:\path`, `SUMMARY:C:\\path` + "\r\n"},
+		{"第一行\n第二行", `SUMMARY:第一行\n第二行` + "\r\n"},
+		{"第一行\r\n第二行\r第三行", `SUMMARY:第一行\n第二行\n第三行` + "\r\n"},
+	}
+	for _, tt := range tests {
+		var b Builder
+		b.Text("SUMMARY", tt.value)
+		if got := string(b.Bytes()); got != tt.want {
+			t.Errorf("Text(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestLineFolding(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		lines int
+	}{
+		{"不超过75字节不折行", strings.Repeat("a", 75-len("DESCRIPTION:")), 1},
+		{"超过75字节折行", strings.Repeat("a", 76-len("DESCRIPTION:")), 2},
+		{"续行计入开头的空格", strings.Repeat("a", 75-len("DESCRIPTION:")+75), 3},
+		{"多字节字符不被截断", strings.Repeat("任务", 60), 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b Builder
+			b.Raw("DESCRIPTION", tt.value)
+			out := string(b.Bytes())
+
+			if !strings.HasSuffix(out, "\r\n") {
+				t.Fatalf("output does not end with CRLF: %q", out)
+			}
+			lines := strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n")
+			if len(lines) != tt.lines {
+				t.Errorf("got %d lines, want %d", len(lines), tt.lines)
+			}
+			for i, line := range lines {
+				if len(line) > maxLineOctets {
+					t.Errorf("line %d has %d octets", i, len(line))
+				}
+				if i > 0 && !strings.HasPrefix(line, " ") {
+					t.Errorf("continuation line %d does not start with a space", i)
+				}
+				if !utf8.ValidString(line) {
+					t.Errorf("line %d splits a multi-byte character", i)
+				}
+			}
+
+			// 去掉折行后应还原原始内容
+			unfolded := strings.ReplaceAll(strings.TrimSuffix(out, "\r\n"), "\r\n ", "")
+			if want := "DESCRIPTION:" + tt.value; unfolded != want {
+				t.Errorf("unfolded = %q, want %q", unfolded, want)
+			}
+		})
+	}
+}
+
+func TestDateAndTime(t *testing.T) {
+	at := time.Date(2025, 1, 2, 23, 30, 0, 0, time.FixedZone("CST", 8*3600))
+
+	var b Builder
+	b.Begin("VEVENT")
+	b.Date("DTSTART", at)
+	b.Time("DTSTAMP", at)
+	b.End("VEVENT")
+
+	want := "BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20250102\r\nDTSTAMP:20250102T153000Z\r\nEND:VEVENT\r\n"
+	if got := string(b.Bytes()); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,73 @@
+package mail
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Message 一封纯文本邮件
+type Message struct {
+	To             string
+	Subject        string
+	Body           string
+	UnsubscribeURL string // 不为空时添加List-Unsubscribe请求头，支持邮件客户端一键退订
+}
+
+// Mailer 邮件发送方式
+type Mailer interface {
+	// Send 发送邮件，返回错误时由调用方决定是否重试
+	Send(ctx context.Context, msg *Message) error
+}
+
+// current 当前使用的发送方式，可通过Init从环境变量配置
+var current Mailer = Log{}
+
+// Init 根据环境变量MAIL_DRIVER初始化邮件发送方式，支持log（默认，只写入日志）和smtp
+func Init() {
+	switch driver := strings.ToLower(os.Getenv("MAIL_DRIVER")); driver {
+	case "", "log":
+		current = Log{}
+		log.Printf("邮件只写入日志，不会实际发送")
+	case "smtp":
+		port := 587
+		if value := os.Getenv("SMTP_PORT"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				log.Fatalf("无效的SMTP端口: %s", value)
+			}
+			port = n
+		}
+		smtp, err := NewSMTP(SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("MAIL_FROM"),
+			TLS:      TLSMode(strings.ToLower(os.Getenv("SMTP_TLS"))),
+		})
+		if err != nil {
+			log.Fatalf("初始化SMTP失败: %v", err)
+		}
+		current = smtp
+		log.Printf("邮件通过SMTP服务器发送: %s", smtp.addr)
+	default:
+		log.Fatalf("不支持的邮件发送方式: %s", driver)
+	}
+}
+
+// Current 获取当前使用的发送方式
+func Current() Mailer {
+	return current
+}
+
+// Log 只把邮件写入日志的发送方式，用于开发环境
+type Log struct{}
+
+// Send 将邮件写入日志
+func (Log) Send(ctx context.Context, msg *Message) error {
+	log.Printf("邮件: 收件人 %s 主题 %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
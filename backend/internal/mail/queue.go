@@ -0,0 +1,113 @@
+package mail
+
+import (
+	"context"
+	"log"
+	"project_management/internal/models"
+	"project_management/internal/repository"
+	"time"
+)
+
+// 发送重试策略：第n次失败后等待 retryBaseDelay*2^(n-1)，最长 retryMaxDelay，共尝试 MaxAttempts 次
+const (
+	MaxAttempts    = 6
+	retryBaseDelay = time.Minute
+	retryMaxDelay  = time.Hour
+)
+
+// 每次定时任务最多发送的邮件数
+const sendBatchSize = 50
+
+// Enqueue 将邮件加入发送队列，由DeliverDue在后台发送
+func Enqueue(delivery *models.EmailDelivery) error {
+	now := time.Now()
+	delivery.Status = models.EmailPending
+	delivery.NextAttemptAt = &now
+	return repository.CreateEmailDelivery(delivery)
+}
+
+// DeliverDue 发送已到时间的邮件，失败的邮件按指数退避安排重试
+func DeliverDue(ctx context.Context) error {
+	deliveries, err := repository.GetDueEmailDeliveries(time.Now(), sendBatchSize)
+	if err != nil {
+		return err
+	}
+
+	sent, failed := 0, 0
+	for i := range deliveries {
+		if ctx.Err() != nil {
+			break
+		}
+		delivery := &deliveries[i]
+		attempt(ctx, delivery)
+		if ctx.Err() != nil {
+			// 服务关闭时中断的发送不计入尝试次数，保持等待状态
+			break
+		}
+		if err := repository.SaveEmailDelivery(delivery); err != nil {
+			return err
+		}
+		if delivery.Status == models.EmailSent {
+			sent++
+		} else {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		log.Printf("邮件发送完成: %d 封成功，%d 封失败", sent, failed)
+	}
+	return nil
+}
+
+// attempt 发送一次邮件，并根据结果更新邮件记录的状态和下次尝试时间
+func attempt(ctx context.Context, delivery *models.EmailDelivery) {
+	delivery.Attempts++
+	delivery.Error = ""
+
+	err := Current().Send(ctx, &Message{
+		To:             delivery.To,
+		Subject:        delivery.Subject,
+		Body:           delivery.Body,
+		UnsubscribeURL: delivery.UnsubscribeURL,
+	})
+	if err == nil {
+		now := time.Now()
+		delivery.Status = models.EmailSent
+		delivery.SentAt = &now
+		delivery.NextAttemptAt = nil
+		return
+	}
+
+	delivery.Error = truncate(err.Error(), 500)
+	if delivery.Attempts >= MaxAttempts {
+		delivery.Status = models.EmailFailed
+		delivery.NextAttemptAt = nil
+		return
+	}
+	next := time.Now().Add(retryDelay(delivery.Attempts))
+	delivery.NextAttemptAt = &next
+}
+
+// retryDelay 计算第attempts次失败后到下次尝试的等待时间
+func retryDelay(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= retryMaxDelay {
+			return retryMaxDelay
+		}
+	}
+	return delay
+}
+
+// truncate 截断过长的文本，保证不截断多字节字符
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && s[max]&0xC0 == 0x80 {
+		max--
+	}
+	return s[:max]
+}
@@ -0,0 +1,190 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 未设置截止时间时单次发送的超时时间
+const defaultTimeout = 30 * time.Second
+
+// TLSMode SMTP连接的加密方式
+type TLSMode string
+
+// SMTP连接加密方式常量
+const (
+	TLSStartTLS TLSMode = "starttls" // 默认：服务器支持STARTTLS时升级为加密连接
+	TLSImplicit TLSMode = "tls"      // 建立连接时即使用TLS，通常为465端口
+	TLSNone     TLSMode = "none"     // 不加密，仅用于本地测试的SMTP服务
+)
+
+// SMTPConfig SMTP服务器的连接配置
+type SMTPConfig struct {
+	Host     string
+	Port     int    // 默认587
+	Username string // 为空时不进行认证
+	Password string
+	From     string  // 发件人，例如 项目管理 <noreply@example.com>
+	TLS      TLSMode // 为空时使用starttls
+}
+
+// SMTP 通过SMTP服务器发送邮件，每封邮件使用一个新连接
+type SMTP struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     *netmail.Address
+	tls      TLSMode
+}
+
+// NewSMTP 根据配置创建SMTP发送方式
+func NewSMTP(cfg SMTPConfig) (*SMTP, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("未配置SMTP_HOST")
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	if cfg.Port < 1 || cfg.Port > 65535 {
+		return nil, fmt.Errorf("无效的SMTP端口: %d", cfg.Port)
+	}
+	if cfg.TLS == "" {
+		cfg.TLS = TLSStartTLS
+	}
+	if cfg.TLS != TLSStartTLS && cfg.TLS != TLSImplicit && cfg.TLS != TLSNone {
+		return nil, fmt.Errorf("无效的SMTP_TLS: %s", cfg.TLS)
+	}
+	if cfg.From == "" {
+		return nil, errors.New("未配置MAIL_FROM")
+	}
+	from, err := netmail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("无效的MAIL_FROM: %s", cfg.From)
+	}
+
+	return &SMTP{
+		addr:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		host:     cfg.Host,
+		username: cfg.Username,
+		password: cfg.Password,
+		from:     from,
+		tls:      cfg.TLS,
+	}, nil
+}
+
+// Send 连接SMTP服务器并发送邮件
+func (s *SMTP) Send(ctx context.Context, msg *Message) error {
+	to, err := netmail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("无效的收件人: %s", msg.To)
+	}
+	data, err := s.build(to, msg)
+	if err != nil {
+		return err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultTimeout)
+	}
+	dialer := &net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+	if s.tls == TLSImplicit {
+		conn = tls.Client(conn, &tls.Config{ServerName: s.host})
+	}
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if s.tls == TLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+				return err
+			}
+		}
+	}
+	if s.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.username, s.password, s.host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(s.from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// build 生成邮件内容，正文使用base64编码的UTF-8纯文本
+func (s *SMTP) build(to *netmail.Address, msg *Message) ([]byte, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	domain := s.from.Address[strings.LastIndex(s.from.Address, "@")+1:]
+
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		buf.WriteString(name + ": " + value + "\r\n")
+	}
+	header("From", s.from.String())
+	header("To", to.String())
+	header("Subject", mime.BEncoding.Encode("UTF-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", "<"+hex.EncodeToString(id)+"@"+domain+">")
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=UTF-8")
+	header("Content-Transfer-Encoding", "base64")
+	if msg.UnsubscribeURL != "" {
+		header("List-Unsubscribe", "<"+msg.UnsubscribeURL+">")
+		header("List-Unsubscribe-Post", "List-Unsubscribe=One-Click")
+	}
+	buf.WriteString("\r\n")
+
+	// 正文按每行76个字符折行
+	body := base64.StdEncoding.EncodeToString([]byte(msg.Body))
+	for len(body) > 76 {
+		buf.WriteString(body[:76] + "\r\n")
+		body = body[76:]
+	}
+	buf.WriteString(body + "\r\n")
+	return buf.Bytes(), nil
+}
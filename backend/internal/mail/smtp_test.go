@@ -0,0 +1,235 @@
+package mail
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"net"
+	netmail "net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// session 测试SMTP服务器收到的一次会话
+type session struct {
+	auth string
+	from string
+	to   string
+	data []byte
+}
+
+// startSMTPServer 启动只处理基本命令的本地SMTP服务器，rejectRcpt为true时拒绝所有收件人
+func startSMTPServer(t *testing.T, rejectRcpt bool) (int, <-chan session) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	sessions := make(chan session, 1)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			serveSMTP(conn, rejectRcpt, sessions)
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port, sessions
+}
+
+func serveSMTP(conn net.Conn, rejectRcpt bool, sessions chan<- session) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	var s session
+	reply := func(lines ...string) {
+		for _, line := range lines {
+			tp.PrintfLine("%s", line)
+		}
+	}
+
+	reply("220 localhost ESMTP test")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch command {
+		case "EHLO":
+			reply("250-localhost", "250 AUTH PLAIN")
+		case "AUTH":
+			s.auth = line
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			s.from = line
+			reply("250 2.1.0 Ok")
+		case "RCPT":
+			if rejectRcpt {
+				reply("550 5.1.1 User unknown")
+				continue
+			}
+			s.to = line
+			reply("250 2.1.5 Ok")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			s.data, err = io.ReadAll(tp.DotReader())
+			if err != nil {
+				return
+			}
+			reply("250 2.0.0 Ok: queued")
+		case "QUIT":
+			reply("221 2.0.0 Bye")
+			sessions <- s
+			return
+		default:
+			reply("250 Ok")
+		}
+	}
+}
+
+func TestSMTPSend(t *testing.T) {
+	body := strings.Repeat("你负责的任务「发布」将于2025-03-01截止。\n", 5)
+
+	tests := []struct {
+		name        string
+		username    string
+		msg         Message
+		wantAuth    string
+		wantSubject string
+		unsubscribe string
+	}{
+		{
+			name:        "退订链接和认证",
+			username:    "mailer",
+			msg:         Message{To: "张三 <zhang@example.com>", Subject: "任务即将截止：发布", Body: body, UnsubscribeURL: "https://pm.example.com/api/email/unsubscribe/abc?type=all"},
+			wantAuth:    "AUTH PLAIN " + base64.StdEncoding.EncodeToString([]byte("\x00mailer\x00secret")),
+			wantSubject: "任务即将截止：发布",
+			unsubscribe: "<https://pm.example.com/api/email/unsubscribe/abc?type=all>",
+		},
+		{
+			name:        "无退订链接时不添加退订请求头",
+			msg:         Message{To: "zhang@example.com", Subject: "验证你的邮箱", Body: "请打开以下链接验证你的邮箱"},
+			wantSubject: "验证你的邮箱",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			port, sessions := startSMTPServer(t, false)
+			s, err := NewSMTP(SMTPConfig{
+				Host:     "127.0.0.1",
+				Port:     port,
+				Username: tt.username,
+				Password: "secret",
+				From:     "项目管理 <noreply@pm.example.com>",
+				TLS:      TLSNone,
+			})
+			if err != nil {
+				t.Fatalf("NewSMTP() error = %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := s.Send(ctx, &tt.msg); err != nil {
+				t.Fatalf("Send() error = %v", err)
+			}
+			got := <-sessions
+
+			if got.auth != tt.wantAuth {
+				t.Errorf("auth = %q, want %q", got.auth, tt.wantAuth)
+			}
+			if got.from != "MAIL FROM:<noreply@pm.example.com>" || got.to != "RCPT TO:<zhang@example.com>" {
+				t.Errorf("envelope = %q %q", got.from, got.to)
+			}
+
+			message, err := netmail.ReadMessage(strings.NewReader(string(got.data)))
+			if err != nil {
+				t.Fatalf("ReadMessage() error = %v", err)
+			}
+			header := message.Header
+
+			subject, err := new(mime.WordDecoder).DecodeHeader(header.Get("Subject"))
+			if err != nil || subject != tt.wantSubject {
+				t.Errorf("Subject = %q (%v), want %q", subject, err, tt.wantSubject)
+			}
+			from, err := header.AddressList("From")
+			if err != nil || len(from) != 1 || from[0].Name != "项目管理" || from[0].Address != "noreply@pm.example.com" {
+				t.Errorf("From = %q (%v)", header.Get("From"), err)
+			}
+			if !strings.HasSuffix(header.Get("Message-Id"), "@pm.example.com>") {
+				t.Errorf("Message-ID = %q", header.Get("Message-Id"))
+			}
+			if _, err := header.Date(); err != nil {
+				t.Errorf("Date = %q (%v)", header.Get("Date"), err)
+			}
+			if header.Get("Content-Type") != "text/plain; charset=UTF-8" || header.Get("Content-Transfer-Encoding") != "base64" {
+				t.Errorf("Content-Type = %q, Content-Transfer-Encoding = %q", header.Get("Content-Type"), header.Get("Content-Transfer-Encoding"))
+			}
+			if got := header.Get("List-Unsubscribe"); got != tt.unsubscribe {
+				t.Errorf("List-Unsubscribe = %q, want %q", got, tt.unsubscribe)
+			}
+			if got, want := header.Get("List-Unsubscribe-Post") != "", tt.unsubscribe != ""; got != want {
+				t.Errorf("List-Unsubscribe-Post = %q", header.Get("List-Unsubscribe-Post"))
+			}
+
+			encoded, err := io.ReadAll(message.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Fields(string(encoded))
+			for i, line := range lines {
+				if len(line) > 76 {
+					t.Errorf("body line %d has %d characters", i, len(line))
+				}
+			}
+			decoded, err := base64.StdEncoding.DecodeString(strings.Join(lines, ""))
+			if err != nil || string(decoded) != tt.msg.Body {
+				t.Errorf("body = %q (%v), want %q", decoded, err, tt.msg.Body)
+			}
+		})
+	}
+}
+
+func TestSMTPSendRejectedRecipient(t *testing.T) {
+	port, _ := startSMTPServer(t, true)
+	s, err := NewSMTP(SMTPConfig{Host: "127.0.0.1", Port: port, From: "noreply@example.com", TLS: TLSNone})
+	if err != nil {
+		t.Fatalf("NewSMTP() error = %v", err)
+	}
+
+	err = s.Send(context.Background(), &Message{To: "nobody@example.com", Subject: "测试", Body: "测试"})
+	if err == nil || !strings.Contains(err.Error(), "550") {
+		t.Errorf("Send() error = %v, want 550", err)
+	}
+}
+
+func TestNewSMTP(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     SMTPConfig
+		wantErr bool
+	}{
+		{"默认端口和加密方式", SMTPConfig{Host: "smtp.example.com", From: "noreply@example.com"}, false},
+		{"隐式TLS", SMTPConfig{Host: "smtp.example.com", Port: 465, From: "noreply@example.com", TLS: TLSImplicit}, false},
+		{"缺少主机", SMTPConfig{From: "noreply@example.com"}, true},
+		{"无效端口", SMTPConfig{Host: "smtp.example.com", Port: 70000, From: "noreply@example.com"}, true},
+		{"无效加密方式", SMTPConfig{Host: "smtp.example.com", From: "noreply@example.com", TLS: "ssl"}, true},
+		{"缺少发件人", SMTPConfig{Host: "smtp.example.com"}, true},
+		{"无效发件人", SMTPConfig{Host: "smtp.example.com", From: "项目管理"}, true},
+	}
+	for _, tt := range tests {
+		s, err := NewSMTP(tt.cfg)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: NewSMTP() error = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && tt.cfg.Port == 0 && (s.addr != "smtp.example.com:587" || s.tls != TLSStartTLS) {
+			t.Errorf("%s: addr = %s tls = %s, want smtp.example.com:587 starttls", tt.name, s.addr, s.tls)
+		}
+	}
+}
@@ -27,25 +27,52 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		tokenString := tokenParts[1]
-
-		// 验证令牌
-		claims, err := auth.ValidateToken(tokenString)
-		if err != nil {
-			if err == auth.ErrExpiredToken {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "令牌已过期", "code": "token_expired"})
-			} else {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的令牌"})
-			}
+		authenticate(c, tokenParts[1])
+	}
+}
+
+// StreamAuthMiddleware 事件流的身份验证中间件，浏览器的EventSource无法设置请求头，
+// 因此在没有Authorization请求头时也接受查询参数access_token中的访问令牌
+func StreamAuthMiddleware() gin.HandlerFunc {
+	headerAuth := AuthMiddleware()
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "" {
+			headerAuth(c)
+			return
+		}
+
+		tokenString := c.Query("access_token")
+		if tokenString == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "未提供授权令牌"})
 			c.Abort()
 			return
 		}
 
-		// 将用户信息添加到上下文
-		c.Set("userID", claims.UserID)
-		c.Set("username", claims.Subject)
-		c.Set("name", claims.Name)
+		authenticate(c, tokenString)
+	}
+}
+
+// authenticate 验证访问令牌并将用户信息添加到上下文，失败时中止请求
+func authenticate(c *gin.Context, tokenString string) {
+	// 验证令牌
+	claims, err := auth.ValidateToken(tokenString)
+	if err != nil {
+		if err == auth.ErrExpiredToken {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "令牌已过期", "code": "token_expired"})
+		} else {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的令牌"})
+		}
+		c.Abort()
+		return
+	}
 
-		c.Next()
+	// 将用户信息添加到上下文
+	c.Set("userID", claims.UserID)
+	c.Set("username", claims.Subject)
+	c.Set("name", claims.Name)
+	if claims.ExpiresAt != nil {
+		c.Set("tokenExpiresAt", claims.ExpiresAt.Time)
 	}
+
+	c.Next()
 } 
\ No newline at end of file
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"project_management/internal/models"
+	"project_management/internal/repository"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireProjectPermission 项目权限中间件，从路径参数id中读取项目ID并校验当前用户的权限
+func RequireProjectPermission(permission models.ProjectPermission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+			c.Abort()
+			return
+		}
+
+		project, err := repository.GetProjectByID(uint(id))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目失败"})
+			c.Abort()
+			return
+		}
+
+		if project == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "项目不存在"})
+			c.Abort()
+			return
+		}
+
+		if !CheckProjectPermission(c, project.ID, permission) {
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// CheckProjectPermission 检查当前用户在项目中是否拥有指定权限。
+// 没有权限时写入403响应并返回false，调用方直接返回即可。
+func CheckProjectPermission(c *gin.Context, projectID uint, permission models.ProjectPermission) bool {
+	member, err := repository.GetProjectMember(projectID, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目成员失败"})
+		return false
+	}
+
+	if member == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "您不是该项目的成员", "code": "not_project_member"})
+		return false
+	}
+
+	if !member.Role.Can(permission) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":      "权限不足",
+			"code":       "insufficient_permission",
+			"permission": permission,
+			"role":       member.Role,
+		})
+		return false
+	}
+
+	c.Set("projectRole", member.Role)
+	return true
+}
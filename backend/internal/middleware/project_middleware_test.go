@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"project_management/internal/models"
+	"project_management/internal/repository"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupProject 使用临时SQLite数据库创建项目1，用户1至4依次为所有者、管理员、成员和观察者，用户5不是成员
+func setupProject(t *testing.T) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Project{}, &models.ProjectMember{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	previous := repository.DB
+	repository.DB = db
+	t.Cleanup(func() { repository.DB = previous })
+
+	db.Create(&models.Project{ID: 1, Name: "项目", OwnerID: 1})
+	roles := []models.ProjectRole{models.ProjectRoleOwner, models.ProjectRoleAdmin, models.ProjectRoleMember, models.ProjectRoleViewer}
+	for i, role := range roles {
+		db.Create(&models.ProjectMember{ProjectID: 1, UserID: uint(i + 1), Role: role})
+	}
+}
+
+// requestProject 以userID的身份请求受permission保护的项目接口
+func requestProject(t *testing.T, projectID string, userID uint, permission models.ProjectPermission) (*httptest.ResponseRecorder, gin.H) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/projects/:id",
+		func(c *gin.Context) { c.Set("userID", userID) },
+		RequireProjectPermission(permission),
+		func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{}) },
+	)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/projects/"+projectID, nil))
+	var body gin.H
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return w, body
+}
+
+func TestRequireProjectPermission(t *testing.T) {
+	setupProject(t)
+
+	permissions := []models.ProjectPermission{
+		models.PermissionView,
+		models.PermissionComment,
+		models.PermissionEdit,
+		models.PermissionManage,
+		models.PermissionDeleteProject,
+	}
+	// 各角色拥有上面列表中的前granted项权限
+	tests := []struct {
+		userID  uint
+		role    models.ProjectRole
+		granted int
+	}{
+		{1, models.ProjectRoleOwner, 5},
+		{2, models.ProjectRoleAdmin, 4},
+		{3, models.ProjectRoleMember, 3},
+		{4, models.ProjectRoleViewer, 2},
+	}
+	for _, tt := range tests {
+		for i, permission := range permissions {
+			t.Run(string(tt.role)+"/"+string(permission), func(t *testing.T) {
+				w, body := requestProject(t, "1", tt.userID, permission)
+				if i < tt.granted {
+					if w.Code != http.StatusOK {
+						t.Errorf("status = %d, want 200 (%v)", w.Code, body)
+					}
+					return
+				}
+				if w.Code != http.StatusForbidden || body["code"] != "insufficient_permission" {
+					t.Fatalf("response = %d %v, want 403 insufficient_permission", w.Code, body)
+				}
+				if body["permission"] != string(permission) || body["role"] != string(tt.role) {
+					t.Errorf("permission = %v role = %v, want %s %s", body["permission"], body["role"], permission, tt.role)
+				}
+			})
+		}
+	}
+}
+
+func TestRequireProjectPermissionErrors(t *testing.T) {
+	setupProject(t)
+
+	tests := []struct {
+		name       string
+		projectID  string
+		userID     uint
+		wantStatus int
+		wantCode   string
+	}{
+		{"不是项目成员", "1", 5, http.StatusForbidden, "not_project_member"},
+		{"项目不存在", "2", 1, http.StatusNotFound, ""},
+		{"无效的项目ID", "abc", 1, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, body := requestProject(t, tt.projectID, tt.userID, models.PermissionView)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (%v)", w.Code, tt.wantStatus, body)
+			}
+			if code, _ := body["code"].(string); code != tt.wantCode {
+				t.Errorf("code = %q, want %q", code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestCheckProjectPermissionSetsRole(t *testing.T) {
+	setupProject(t)
+	gin.SetMode(gin.TestMode)
+
+	for userID := uint(1); userID <= 4; userID++ {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("userID", userID)
+
+		if !CheckProjectPermission(c, 1, models.PermissionView) {
+			t.Fatalf("user %d: CheckProjectPermission() = false, response %d %s", userID, w.Code, w.Body)
+		}
+		member, _ := repository.GetProjectMember(1, userID)
+		if role, _ := c.Get("projectRole"); role != member.Role {
+			t.Errorf("user %d: projectRole = %v, want %s", userID, role, member.Role)
+		}
+		if w.Body.Len() != 0 {
+			t.Errorf("user %d: response written on success: %s", userID, w.Body)
+		}
+	}
+}
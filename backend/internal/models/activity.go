@@ -0,0 +1,176 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EntityType 操作记录关联的实体类型
+type EntityType string
+
+// 实体类型常量
+const (
+	EntityTask      EntityType = "task"
+	EntityMilestone EntityType = "milestone"
+)
+
+// IsValid 检查实体类型是否合法
+func (t EntityType) IsValid() bool {
+	return t == EntityTask || t == EntityMilestone
+}
+
+// ActivityAction 操作类型
+type ActivityAction string
+
+// 操作类型常量
+const (
+	ActivityCreate  ActivityAction = "create"
+	ActivityUpdate  ActivityAction = "update"
+	ActivityDelete  ActivityAction = "delete"
+	ActivityRestore ActivityAction = "restore"
+)
+
+// IsValid 检查操作类型是否合法
+func (a ActivityAction) IsValid() bool {
+	return a == ActivityCreate || a == ActivityUpdate || a == ActivityDelete || a == ActivityRestore
+}
+
+// FieldChange 单个字段的变更前后值，创建时before为null，删除时after为null
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// FieldChanges 字段名到变更内容的映射，以JSON格式存储
+type FieldChanges map[string]FieldChange
+
+// Value 实现driver.Valuer接口
+func (c FieldChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan 实现sql.Scanner接口
+func (c *FieldChanges) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*c = FieldChanges{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("无法解析字段变更记录")
+	}
+	return json.Unmarshal(data, c)
+}
+
+// Activity 操作记录模型，记录任务和里程碑的创建、修改、删除和恢复
+type Activity struct {
+	ID         uint           `json:"id" gorm:"primarykey"`
+	ProjectID  uint           `json:"project_id" gorm:"not null;index"`
+	ActorID    *uint          `json:"actor_id" gorm:"index"` // 为空表示系统操作
+	EntityType EntityType     `json:"entity_type" gorm:"size:20;not null;index:idx_activity_entity"`
+	EntityID   uint           `json:"entity_id" gorm:"not null;index:idx_activity_entity"`
+	Action     ActivityAction `json:"action" gorm:"size:20;not null"`
+	Changes    FieldChanges   `json:"changes" gorm:"type:text"`
+	CreatedAt  time.Time      `json:"created_at" gorm:"index"`
+
+	Actor *User `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+}
+
+// BeforeCreate 创建操作记录前的处理
+func (a *Activity) BeforeCreate(tx *gorm.DB) error {
+	a.CreatedAt = time.Now()
+	return nil
+}
+
+// activityEventSuffixes 操作类型对应的事件名后缀
+var activityEventSuffixes = map[ActivityAction]string{
+	ActivityCreate:  "created",
+	ActivityUpdate:  "updated",
+	ActivityDelete:  "deleted",
+	ActivityRestore: "restored",
+}
+
+// EventName 操作记录对应的事件名，如task.created、milestone.deleted
+func (a *Activity) EventName() string {
+	return string(a.EntityType) + "." + activityEventSuffixes[a.Action]
+}
+
+// Snapshot 实体中需要记录变更的字段
+type Snapshot map[string]interface{}
+
+// TaskSnapshot 生成任务的字段快照
+func TaskSnapshot(t *Task) Snapshot {
+	var milestoneID, parentID, startDate interface{}
+	if t.MilestoneID != nil {
+		milestoneID = *t.MilestoneID
+	}
+	if t.ParentID != nil {
+		parentID = *t.ParentID
+	}
+	if t.StartDate != nil {
+		startDate = t.StartDate.Format("2006-01-02")
+	}
+
+	assigneeIDs := make([]uint, len(t.Assignees))
+	for i, user := range t.Assignees {
+		assigneeIDs[i] = user.ID
+	}
+	sort.Slice(assigneeIDs, func(i, j int) bool { return assigneeIDs[i] < assigneeIDs[j] })
+
+	return Snapshot{
+		"project_id":     t.ProjectID,
+		"milestone_id":   milestoneID,
+		"parent_id":      parentID,
+		"name":           t.Name,
+		"start_date":     startDate,
+		"deadline":       t.Deadline.Format("2006-01-02"),
+		"estimated_days": t.EstimatedDays,
+		"status":         t.Status,
+		"urgency":        t.Urgency,
+		"assignee_ids":   assigneeIDs,
+	}
+}
+
+// MilestoneSnapshot 生成里程碑的字段快照
+func MilestoneSnapshot(m *Milestone) Snapshot {
+	return Snapshot{
+		"project_id":  m.ProjectID,
+		"title":       m.Title,
+		"date":        m.Date.Format("2006-01-02"),
+		"description": m.Description,
+	}
+}
+
+// DiffSnapshots 比较两个快照并返回发生变化的字段，before为nil表示创建，after为nil表示删除
+func DiffSnapshots(before, after Snapshot) FieldChanges {
+	changes := FieldChanges{}
+	for field, old := range before {
+		value, ok := after[field]
+		if !ok || !reflect.DeepEqual(old, value) {
+			changes[field] = FieldChange{Before: old, After: value}
+		}
+	}
+	for field, value := range after {
+		if _, ok := before[field]; !ok {
+			changes[field] = FieldChange{Before: nil, After: value}
+		}
+	}
+	return changes
+}
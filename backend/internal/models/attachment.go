@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Attachment 任务附件模型，文件内容保存在存储后端，数据库只记录元数据
+type Attachment struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	TaskID      uint      `json:"task_id" gorm:"not null;index"`
+	UploaderID  uint      `json:"uploader_id" gorm:"not null;index"`
+	FileName    string    `json:"file_name" gorm:"size:255;not null"`
+	ContentType string    `json:"content_type" gorm:"size:100;not null"`
+	Size        int64     `json:"size" gorm:"not null"`
+	Checksum    string    `json:"checksum" gorm:"size:64"`                // 文件内容的SHA256
+	StorageKey  string    `json:"-" gorm:"size:255;not null;uniqueIndex"` // 存储后端中的对象键
+	CreatedAt   time.Time `json:"created_at"`
+
+	Uploader *User `json:"uploader,omitempty" gorm:"foreignKey:UploaderID"`
+}
+
+// BeforeCreate 创建附件记录前的处理
+func (a *Attachment) BeforeCreate(tx *gorm.DB) error {
+	a.CreatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Comment 评论模型，挂在任务或里程碑下，通过ParentID形成回复串
+type Comment struct {
+	ID         uint       `json:"id" gorm:"primarykey"`
+	EntityType EntityType `json:"entity_type" gorm:"size:20;not null;index:idx_comment_entity"`
+	EntityID   uint       `json:"entity_id" gorm:"not null;index:idx_comment_entity"`
+	ParentID   *uint      `json:"parent_id" gorm:"index"` // 回复的评论ID，为空表示顶层评论
+	AuthorID   uint       `json:"author_id" gorm:"not null;index"`
+	Content    string     `json:"content" gorm:"type:text;not null"`
+	Deleted    bool       `json:"deleted" gorm:"not null;default:false"` // 已删除但仍有回复的评论保留为占位
+	EditedAt   *time.Time `json:"edited_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	Author   *User     `json:"author,omitempty" gorm:"foreignKey:AuthorID"`
+	Mentions []User    `json:"mentions" gorm:"many2many:comment_mentions"`
+	Replies  []Comment `json:"replies,omitempty" gorm:"-"`
+}
+
+// CommentMention 评论中@提及的用户，供后续通知使用
+type CommentMention struct {
+	CommentID uint `gorm:"primaryKey"`
+	UserID    uint `gorm:"primaryKey;index"`
+	CreatedAt time.Time
+}
+
+// BeforeCreate 创建评论前的处理
+func (c *Comment) BeforeCreate(tx *gorm.DB) error {
+	c.CreatedAt = time.Now()
+	c.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate 更新评论前的处理
+func (c *Comment) BeforeUpdate(tx *gorm.DB) error {
+	c.UpdatedAt = time.Now()
+	return nil
+}
+
+// mentionPattern 匹配@用户名，@前不能紧跟用户名字符，避免误识别邮箱地址
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.\-])@([\p{L}\p{N}_.\-]+)`)
+
+// ParseMentions 解析内容中@提及的用户名，去除重复并忽略末尾的标点
+func ParseMentions(content string) []string {
+	seen := make(map[string]bool)
+	var usernames []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		username := strings.TrimRight(match[1], ".-")
+		if username != "" && !seen[username] {
+			seen[username] = true
+			usernames = append(usernames, username)
+		}
+	}
+	return usernames
+}
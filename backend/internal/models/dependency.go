@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TaskDependency 任务依赖关系：阻塞任务（BlockerID）完成前，被阻塞任务（BlockedID）不能开始或完成
+type TaskDependency struct {
+	BlockerID uint      `json:"blocker_id" gorm:"primaryKey"`
+	BlockedID uint      `json:"blocked_id" gorm:"primaryKey;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BeforeCreate 创建依赖关系前的处理
+func (d *TaskDependency) BeforeCreate(tx *gorm.DB) error {
+	d.CreatedAt = time.Now()
+	return nil
+}
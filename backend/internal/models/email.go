@@ -0,0 +1,113 @@
+package models
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EmailVerificationTTL 邮箱验证链接的有效期
+const EmailVerificationTTL = 24 * time.Hour
+
+// EmailVerification 邮箱验证令牌，用户修改邮箱时生成，验证成功后删除
+type EmailVerification struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	Email     string    `json:"email" gorm:"size:255;not null"` // 待验证的邮箱，与用户当前邮箱不一致时令牌失效
+	Token     string    `json:"-" gorm:"size:64;not null;unique"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BeforeCreate 创建验证令牌前的处理
+func (v *EmailVerification) BeforeCreate(tx *gorm.DB) error {
+	v.CreatedAt = time.Now()
+	return nil
+}
+
+// IsExpired 检查验证令牌是否过期
+func (v *EmailVerification) IsExpired() bool {
+	return time.Now().After(v.ExpiresAt)
+}
+
+// DigestFrequency 邮件摘要的发送频率
+type DigestFrequency string
+
+// 邮件摘要频率常量
+const (
+	DigestOff    DigestFrequency = "off"
+	DigestDaily  DigestFrequency = "daily"
+	DigestWeekly DigestFrequency = "weekly" // 每周一发送
+)
+
+// IsValid 检查摘要频率是否合法
+func (f DigestFrequency) IsValid() bool {
+	return f == DigestOff || f == DigestDaily || f == DigestWeekly
+}
+
+// EmailKind 邮件类型
+type EmailKind string
+
+// 邮件类型常量
+const (
+	EmailVerify       EmailKind = "verify"        // 邮箱验证
+	EmailDeadlineSoon EmailKind = "deadline_soon" // 任务截止提醒
+	EmailDigest       EmailKind = "digest"        // 任务和里程碑摘要
+)
+
+// EmailStatus 邮件的发送状态
+type EmailStatus string
+
+// 邮件发送状态常量
+const (
+	EmailPending EmailStatus = "pending" // 等待发送或等待重试
+	EmailSent    EmailStatus = "sent"
+	EmailFailed  EmailStatus = "failed" // 重试次数用尽
+)
+
+// DefaultEmailRetentionDays 邮件记录的默认保留天数，可通过环境变量EMAIL_RETENTION_DAYS覆盖
+const DefaultEmailRetentionDays = 30
+
+// EmailRetention 已结束的邮件记录的保留时长，超过后由定时任务删除。
+// 截止提醒和摘要依靠邮件记录去重，因此至少保留8天
+func EmailRetention() time.Duration {
+	days := DefaultEmailRetentionDays
+	if n, err := strconv.Atoi(os.Getenv("EMAIL_RETENTION_DAYS")); err == nil && n > 0 {
+		days = max(n, 8)
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// EmailDelivery 待发送和已发送的邮件，由后台任务从队列中发送，失败时按退避策略重试
+type EmailDelivery struct {
+	ID             uint        `json:"id" gorm:"primarykey"`
+	UserID         uint        `json:"user_id" gorm:"not null;index"`
+	Kind           EmailKind   `json:"kind" gorm:"size:20;not null"`
+	To             string      `json:"to" gorm:"column:recipient;size:255;not null"`
+	Subject        string      `json:"subject" gorm:"size:255;not null"`
+	Body           string      `json:"body" gorm:"type:text;not null"`
+	UnsubscribeURL string      `json:"unsubscribe_url" gorm:"size:500"` // 为空表示邮件不可退订，如验证邮件
+	DedupKey       string      `json:"-" gorm:"size:100;index"`         // 用于避免重复发送的键，如截止提醒和摘要的周期
+	Status         EmailStatus `json:"status" gorm:"size:20;not null;index:idx_email_due"`
+	Attempts       int         `json:"attempts" gorm:"not null"`
+	NextAttemptAt  *time.Time  `json:"next_attempt_at" gorm:"index:idx_email_due"` // 发送结束后为空
+	SentAt         *time.Time  `json:"sent_at"`
+	Error          string      `json:"error" gorm:"size:500"` // 最近一次失败的原因
+	CreatedAt      time.Time   `json:"created_at" gorm:"index"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+// BeforeCreate 创建邮件记录前的处理
+func (d *EmailDelivery) BeforeCreate(tx *gorm.DB) error {
+	d.CreatedAt = time.Now()
+	d.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate 更新邮件记录前的处理
+func (d *EmailDelivery) BeforeUpdate(tx *gorm.DB) error {
+	d.UpdatedAt = time.Now()
+	return nil
+}
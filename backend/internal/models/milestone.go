@@ -8,12 +8,25 @@ import (
 
 // Milestone 里程碑模型
 type Milestone struct {
-	ID          uint      `json:"id" gorm:"primarykey"`
-	Title       string    `json:"title" gorm:"size:255;not null"`
-	Date        time.Time `json:"date"`
-	Description string    `json:"description" gorm:"size:1000"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uint           `json:"id" gorm:"primarykey"`
+	ProjectID   uint           `json:"project_id" gorm:"not null;default:0;index"`
+	Title       string         `json:"title" gorm:"size:255;not null"`
+	Date        time.Time      `json:"date"`
+	Description string         `json:"description" gorm:"size:1000"`
+	Version     uint           `json:"version" gorm:"not null;default:1"` // 版本号，每次修改加一，用于检测并发修改冲突
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"` // 删除时间，已删除的里程碑位于回收站中
+
+	Progress *MilestoneProgress `json:"progress,omitempty" gorm:"-"`
+}
+
+// MilestoneProgress 里程碑进度，根据关联任务计算
+type MilestoneProgress struct {
+	Total     int64   `json:"total"`
+	Completed int64   `json:"completed"`
+	Overdue   int64   `json:"overdue"`
+	Percent   float64 `json:"percent"`
 }
 
 // BeforeCreate 创建里程碑前的处理
@@ -27,4 +40,4 @@ func (m *Milestone) BeforeCreate(tx *gorm.DB) error {
 func (m *Milestone) BeforeUpdate(tx *gorm.DB) error {
 	m.UpdatedAt = time.Now()
 	return nil
-} 
\ No newline at end of file
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// NotificationType 通知类型
+type NotificationType string
+
+// 通知类型常量
+const (
+	NotificationAssigned      NotificationType = "assigned"       // 被分配了任务
+	NotificationStatusChanged NotificationType = "status_changed" // 负责的任务状态发生变化
+	NotificationDeadlineSoon  NotificationType = "deadline_soon"  // 负责的任务即将截止
+	NotificationMentioned     NotificationType = "mentioned"      // 在评论中被@提及
+)
+
+// NotificationTypes 全部通知类型
+var NotificationTypes = []NotificationType{
+	NotificationAssigned, NotificationStatusChanged, NotificationDeadlineSoon, NotificationMentioned,
+}
+
+// IsValid 检查通知类型是否合法
+func (t NotificationType) IsValid() bool {
+	for _, notificationType := range NotificationTypes {
+		if t == notificationType {
+			return true
+		}
+	}
+	return false
+}
+
+// Notification 站内通知模型
+type Notification struct {
+	ID         uint             `json:"id" gorm:"primarykey"`
+	UserID     uint             `json:"user_id" gorm:"not null;index:idx_notification_user"` // 接收人
+	Type       NotificationType `json:"type" gorm:"size:30;not null"`
+	ProjectID  uint             `json:"project_id" gorm:"not null;index"`
+	EntityType EntityType       `json:"entity_type" gorm:"size:20;not null;index:idx_notification_entity"`
+	EntityID   uint             `json:"entity_id" gorm:"not null;index:idx_notification_entity"`
+	CommentID  *uint            `json:"comment_id"` // 提及所在的评论
+	ActorID    *uint            `json:"actor_id"`   // 触发通知的用户，系统操作时为空
+	Title      string           `json:"title" gorm:"size:255;not null"`
+	Content    string           `json:"content" gorm:"size:500"`
+	DedupKey   string           `json:"-" gorm:"size:100;index"` // 用于避免重复发送的键，如截止提醒
+	ReadAt     *time.Time       `json:"read_at" gorm:"index:idx_notification_user"`
+	CreatedAt  time.Time        `json:"created_at" gorm:"index"`
+
+	Actor *User `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+}
+
+// BeforeCreate 创建通知前的处理
+func (n *Notification) BeforeCreate(tx *gorm.DB) error {
+	n.CreatedAt = time.Now()
+	return nil
+}
+
+// DefaultDeadlineReminderHours 截止提醒的默认提前小时数，可通过环境变量NOTIFICATION_DEADLINE_HOURS覆盖
+const DefaultDeadlineReminderHours = 24
+
+// MaxDeadlineReminderHours 截止提醒最多提前的小时数
+const MaxDeadlineReminderHours = 168
+
+// NotificationPreference 用户的通知偏好，没有记录时使用DefaultNotificationPreference
+type NotificationPreference struct {
+	UserID            uint            `json:"-" gorm:"primarykey;autoIncrement:false"`
+	Assigned          bool            `json:"assigned" gorm:"not null"`
+	StatusChanged     bool            `json:"status_changed" gorm:"not null"`
+	DeadlineSoon      bool            `json:"deadline_soon" gorm:"not null"`
+	Mentioned         bool            `json:"mentioned" gorm:"not null"`
+	DeadlineHours     int             `json:"deadline_hours" gorm:"not null"`                        // 截止前多少小时提醒
+	EmailDeadlineSoon bool            `json:"email_deadline_soon" gorm:"not null;default:false"`     // 是否同时发送截止提醒邮件
+	EmailDigest       DigestFrequency `json:"email_digest" gorm:"size:10;not null;default:'weekly'"` // 邮件摘要的发送频率
+	UpdatedAt         time.Time       `json:"updated_at"`
+}
+
+// DefaultNotificationPreference 默认的通知偏好：接收全部类型的通知，验证邮箱后接收截止提醒邮件和每周摘要
+func DefaultNotificationPreference(userID uint) NotificationPreference {
+	hours := DefaultDeadlineReminderHours
+	if n, err := strconv.Atoi(os.Getenv("NOTIFICATION_DEADLINE_HOURS")); err == nil && n > 0 && n <= MaxDeadlineReminderHours {
+		hours = n
+	}
+	return NotificationPreference{
+		UserID:        userID,
+		Assigned:      true,
+		StatusChanged: true,
+		DeadlineSoon:  true,
+		Mentioned:     true,
+		DeadlineHours: hours,
+
+		EmailDeadlineSoon: true,
+		EmailDigest:       DigestWeekly,
+	}
+}
+
+// Allows 检查是否接收指定类型的通知
+func (p *NotificationPreference) Allows(t NotificationType) bool {
+	switch t {
+	case NotificationAssigned:
+		return p.Assigned
+	case NotificationStatusChanged:
+		return p.StatusChanged
+	case NotificationDeadlineSoon:
+		return p.DeadlineSoon
+	case NotificationMentioned:
+		return p.Mentioned
+	}
+	return false
+}
+
+// BeforeSave 保存通知偏好前的处理
+func (p *NotificationPreference) BeforeSave(tx *gorm.DB) error {
+	p.UpdatedAt = time.Now()
+	return nil
+}
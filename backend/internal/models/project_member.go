@@ -0,0 +1,78 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ProjectRole 项目成员角色类型
+type ProjectRole string
+
+// 项目成员角色常量
+const (
+	ProjectRoleOwner  ProjectRole = "owner"
+	ProjectRoleAdmin  ProjectRole = "admin"
+	ProjectRoleMember ProjectRole = "member"
+	ProjectRoleViewer ProjectRole = "viewer"
+)
+
+// ProjectPermission 项目权限类型
+type ProjectPermission string
+
+// 项目权限常量
+const (
+	PermissionView          ProjectPermission = "view"           // 查看项目、任务和里程碑
+	PermissionComment       ProjectPermission = "comment"        // 发表评论
+	PermissionEdit          ProjectPermission = "edit"           // 创建、修改、删除任务和里程碑
+	PermissionManage        ProjectPermission = "manage"         // 修改项目信息、管理成员
+	PermissionDeleteProject ProjectPermission = "delete_project" // 删除项目
+)
+
+// rolePermissions 各角色拥有的权限
+var rolePermissions = map[ProjectRole][]ProjectPermission{
+	ProjectRoleOwner:  {PermissionView, PermissionComment, PermissionEdit, PermissionManage, PermissionDeleteProject},
+	ProjectRoleAdmin:  {PermissionView, PermissionComment, PermissionEdit, PermissionManage},
+	ProjectRoleMember: {PermissionView, PermissionComment, PermissionEdit},
+	ProjectRoleViewer: {PermissionView, PermissionComment},
+}
+
+// IsValid 检查角色是否合法
+func (r ProjectRole) IsValid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Can 检查角色是否拥有指定权限
+func (r ProjectRole) Can(permission ProjectPermission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// ProjectMember 项目成员模型
+type ProjectMember struct {
+	ID        uint        `json:"id" gorm:"primarykey"`
+	ProjectID uint        `json:"project_id" gorm:"not null;uniqueIndex:idx_project_member"`
+	UserID    uint        `json:"user_id" gorm:"not null;uniqueIndex:idx_project_member;index"`
+	Role      ProjectRole `json:"role" gorm:"size:20;not null"`
+	User      *User       `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// BeforeCreate 创建成员前的处理
+func (m *ProjectMember) BeforeCreate(tx *gorm.DB) error {
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate 更新成员前的处理
+func (m *ProjectMember) BeforeUpdate(tx *gorm.DB) error {
+	m.UpdatedAt = time.Now()
+	return nil
+}
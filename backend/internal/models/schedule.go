@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// ProjectSchedule 项目排期，根据未完成任务的预计工期和依赖关系按关键路径法计算
+type ProjectSchedule struct {
+	ProjectID    uint                `json:"project_id"`
+	StartDate    time.Time           `json:"start_date"`    // 排期起点，所有任务最早从这一天开始
+	FinishDate   *time.Time          `json:"finish_date"`   // 全部任务的最早完工日期，没有未完成任务时为空
+	CriticalPath []uint              `json:"critical_path"` // 关键任务ID，按最早开始日期排序
+	Tasks        []TaskSchedule      `json:"tasks"`
+	Milestones   []MilestoneSchedule `json:"milestones"`
+}
+
+// TaskSchedule 单个任务的排期，日期均为含当天的日期
+type TaskSchedule struct {
+	TaskID         uint       `json:"task_id"`
+	Name           string     `json:"name"`
+	Status         TaskStatus `json:"status"`
+	MilestoneID    *uint      `json:"milestone_id"`
+	EstimatedDays  int        `json:"estimated_days"`
+	Deadline       time.Time  `json:"deadline"`
+	EarliestStart  time.Time  `json:"earliest_start"`
+	EarliestFinish time.Time  `json:"earliest_finish"`
+	LatestStart    time.Time  `json:"latest_start"`
+	LatestFinish   time.Time  `json:"latest_finish"`
+	Slack          int        `json:"slack"`    // 总时差（天），为负数表示无法按截止日期或里程碑日期完成
+	Critical       bool       `json:"critical"` // 是否在关键路径上
+	Late           bool       `json:"late"`     // 最早完成日期晚于截止日期
+}
+
+// MilestoneSchedule 里程碑的排期，根据关联的未完成任务计算
+type MilestoneSchedule struct {
+	MilestoneID   uint       `json:"milestone_id"`
+	Title         string     `json:"title"`
+	Date          time.Time  `json:"date"`
+	ProjectedDate *time.Time `json:"projected_date"` // 关联任务的最早完成日期，没有未完成任务时为空
+	AtRisk        bool       `json:"at_risk"`        // 里程碑日期无法达成
+	LateTaskIDs   []uint     `json:"late_task_ids"`  // 截止日期或最早完成日期晚于里程碑日期的任务
+}
@@ -0,0 +1,23 @@
+package models
+
+// TaskStats 任务统计数据
+type TaskStats struct {
+	Total              int64                 `json:"total"`
+	ByStatus           map[TaskStatus]int64  `json:"by_status"`
+	ByUrgency          map[TaskUrgency]int64 `json:"by_urgency"`
+	Overdue            int64                 `json:"overdue"`
+	Unassigned         int64                 `json:"unassigned"`
+	CompletionRate     float64               `json:"completion_rate"`
+	UpcomingMilestones []Milestone           `json:"upcoming_milestones"`
+	Workload           []AssigneeWorkload    `json:"workload"`
+}
+
+// AssigneeWorkload 负责人的任务负载
+type AssigneeWorkload struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	Total    int64  `json:"total"`
+	Open     int64  `json:"open"`
+	Overdue  int64  `json:"overdue"`
+}
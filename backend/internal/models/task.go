@@ -17,27 +17,112 @@ const (
 	TaskStatusDelayed   TaskStatus = "已延期"
 )
 
+// TaskStatuses 所有合法的任务状态
+var TaskStatuses = []TaskStatus{TaskStatusPending, TaskStatusInProcess, TaskStatusCompleted, TaskStatusDelayed}
+
+// IsValid 检查任务状态是否合法
+func (s TaskStatus) IsValid() bool {
+	for _, status := range TaskStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
 // TaskUrgency 任务紧急程度类型
 type TaskUrgency string
 
 // 任务紧急程度常量
 const (
-	TaskUrgencyLow      TaskUrgency = "低"
-	TaskUrgencyMedium   TaskUrgency = "中"
-	TaskUrgencyHigh     TaskUrgency = "高"
-	TaskUrgencyUrgent   TaskUrgency = "紧急"
+	TaskUrgencyLow    TaskUrgency = "低"
+	TaskUrgencyMedium TaskUrgency = "中"
+	TaskUrgencyHigh   TaskUrgency = "高"
+	TaskUrgencyUrgent TaskUrgency = "紧急"
 )
 
+// TaskUrgencies 所有合法的任务紧急程度
+var TaskUrgencies = []TaskUrgency{TaskUrgencyLow, TaskUrgencyMedium, TaskUrgencyHigh, TaskUrgencyUrgent}
+
+// IsValid 检查任务紧急程度是否合法
+func (u TaskUrgency) IsValid() bool {
+	for _, urgency := range TaskUrgencies {
+		if u == urgency {
+			return true
+		}
+	}
+	return false
+}
+
 // Task 任务模型
 type Task struct {
-	ID        uint       `json:"id" gorm:"primarykey"`
-	Name      string     `json:"name" gorm:"size:255;not null"`
-	Deadline  time.Time  `json:"deadline"`
-	Status    TaskStatus `json:"status" gorm:"size:20;not null;default:'待处理'"`
-	Urgency   TaskUrgency `json:"urgency" gorm:"size:20;not null;default:'中'"`
-	Assignee  string     `json:"assignee" gorm:"size:50"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
+	ID            uint           `json:"id" gorm:"primarykey"`
+	ProjectID     uint           `json:"project_id" gorm:"not null;default:0;index"`
+	MilestoneID   *uint          `json:"milestone_id" gorm:"index"`
+	ParentID      *uint          `json:"parent_id" gorm:"index"` // 父任务ID，为空表示顶层任务
+	Name          string         `json:"name" gorm:"size:255;not null"`
+	StartDate     *time.Time     `json:"start_date"` // 计划开始日期，为空时按截止日期和预计工期推算
+	Deadline      time.Time      `json:"deadline"`
+	EstimatedDays int            `json:"estimated_days" gorm:"not null;default:1"` // 预计工期（天），用于排期计算
+	Status        TaskStatus     `json:"status" gorm:"size:20;not null;default:'待处理'"`
+	Urgency       TaskUrgency    `json:"urgency" gorm:"size:20;not null;default:'中'"`
+	Assignees     []User         `json:"assignees" gorm:"many2many:task_assignees"`
+	AutoDelayedAt *time.Time     `json:"auto_delayed_at"`                   // 被系统自动标记为已延期的时间，手动修改状态后清空
+	StartedAt     *time.Time     `json:"started_at"`                        // 首次进入进行中的时间
+	CompletedAt   *time.Time     `json:"completed_at"`                      // 进入已完成的时间，重新打开后清空
+	Version       uint           `json:"version" gorm:"not null;default:1"` // 版本号，每次修改加一，用于检测并发修改冲突
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"` // 删除时间，已删除的任务位于回收站中
+
+	// 父任务或里程碑移入回收站时解除关联的父任务ID和里程碑ID，恢复时重新关联
+	TrashedParentID    *uint `json:"-" gorm:"index"`
+	TrashedMilestoneID *uint `json:"-" gorm:"index"`
+
+	Rollup *TaskRollup `json:"rollup,omitempty" gorm:"-"`
+}
+
+// TaskRollup 子任务汇总，根据全部下级任务计算，没有子任务时为空
+type TaskRollup struct {
+	Total            int64      `json:"total"`
+	Completed        int64      `json:"completed"`
+	Percent          float64    `json:"percent"`
+	EarliestDeadline *time.Time `json:"earliest_deadline"`
+}
+
+// TaskAssignee 任务负责人关联表
+type TaskAssignee struct {
+	TaskID    uint      `json:"task_id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"primaryKey;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PlannedStart 返回任务的计划开始日期，未设置时为截止日期往前推预计工期
+func (t *Task) PlannedStart() time.Time {
+	if t.StartDate != nil {
+		return *t.StartDate
+	}
+	days := t.EstimatedDays
+	if days < 1 {
+		days = 1
+	}
+	return t.Deadline.AddDate(0, 0, 1-days)
+}
+
+// OverdueCutoff 返回逾期判断的时间点：截止日期早于当天零点且未完成的任务视为逾期
+func OverdueCutoff() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
+
+// IsOverdue 检查任务是否已逾期
+func (t *Task) IsOverdue() bool {
+	return t.Status != TaskStatusCompleted && t.Deadline.Before(OverdueCutoff())
+}
+
+// DueAt 返回任务的截止时刻，即截止日期当天结束时，与OverdueCutoff使用相同的时区
+func (t *Task) DueAt() time.Time {
+	return time.Date(t.Deadline.Year(), t.Deadline.Month(), t.Deadline.Day()+1, 0, 0, 0, 0, time.Local)
 }
 
 // BeforeCreate 创建任务前的处理
@@ -51,4 +136,4 @@ func (t *Task) BeforeCreate(tx *gorm.DB) error {
 func (t *Task) BeforeUpdate(tx *gorm.DB) error {
 	t.UpdatedAt = time.Now()
 	return nil
-} 
\ No newline at end of file
+}
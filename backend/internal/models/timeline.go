@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Timeline 甘特图数据，任务按项目和里程碑分组，组内父任务在前、子任务紧随其后
+type Timeline struct {
+	From         time.Time         `json:"from"`
+	To           time.Time         `json:"to"`
+	Projects     []TimelineProject `json:"projects"`
+	Dependencies []TaskDependency  `json:"dependencies"` // 时间范围内任务之间的依赖关系
+}
+
+// TimelineProject 时间线中的项目
+type TimelineProject struct {
+	ProjectID uint            `json:"project_id"`
+	Name      string          `json:"name"`
+	Groups    []TimelineGroup `json:"groups"`
+}
+
+// TimelineGroup 按里程碑分组的任务，按里程碑日期排序，未关联里程碑的任务排在最后
+type TimelineGroup struct {
+	Milestone *Milestone     `json:"milestone"` // 为空表示未关联里程碑的任务
+	Tasks     []TimelineTask `json:"tasks"`
+}
+
+// TimelineTask 时间线中的任务条
+type TimelineTask struct {
+	ID             uint        `json:"id"`
+	ParentID       *uint       `json:"parent_id"`
+	Depth          int         `json:"depth"` // 在组内任务树中的层级，顶层为0
+	Name           string      `json:"name"`
+	Status         TaskStatus  `json:"status"`
+	Urgency        TaskUrgency `json:"urgency"`
+	Start          time.Time   `json:"start"`
+	End            time.Time   `json:"end"`
+	StartEstimated bool        `json:"start_estimated"` // 未设置开始日期，按截止日期和预计工期推算
+	Assignees      []User      `json:"assignees"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// DefaultTrashRetentionDays 回收站的默认保留天数，可通过环境变量TRASH_RETENTION_DAYS覆盖
+const DefaultTrashRetentionDays = 30
+
+// TrashItem 回收站中的任务或里程碑
+type TrashItem struct {
+	EntityType EntityType `json:"entity_type"`
+	ID         uint       `json:"id"`
+	ProjectID  uint       `json:"project_id"`
+	Name       string     `json:"name"` // 任务名称或里程碑标题
+	DeletedAt  time.Time  `json:"deleted_at"`
+	PurgeAt    time.Time  `json:"purge_at" gorm:"-"` // 超过保留期后彻底删除的时间
+}
+
+// TrashRetention 回收站的保留时长，超过后由定时任务彻底删除
+func TrashRetention() time.Duration {
+	days := DefaultTrashRetentionDays
+	if n, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS")); err == nil && n > 0 {
+		days = n
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
@@ -9,12 +9,22 @@ import (
 
 // User 用户模型
 type User struct {
-	ID        uint      `json:"id" gorm:"primarykey"`
-	Username  string    `json:"username" gorm:"size:50;not null;unique"`
-	Password  string    `json:"-" gorm:"size:100;not null"`
-	Name      string    `json:"name" gorm:"size:50"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID               uint           `json:"id" gorm:"primarykey"`
+	Username         string         `json:"username" gorm:"size:50;not null;unique"`
+	Password         string         `json:"-" gorm:"size:100;not null"`
+	Name             string         `json:"name" gorm:"size:50"`
+	Email            *string        `json:"-" gorm:"size:255;uniqueIndex"` // 邮箱，只在本人的用户信息中返回
+	EmailVerifiedAt  *time.Time     `json:"-"`                             // 邮箱验证时间，为空表示未验证，未验证的邮箱不会收到通知邮件
+	UnsubscribeToken *string        `json:"-" gorm:"size:64;uniqueIndex"`  // 邮件中退订链接使用的令牌
+	CalendarToken    *string        `json:"-" gorm:"size:64;uniqueIndex"`  // 日历订阅令牌，为空表示未开启订阅
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"` // 注销时间，保留期内可以恢复
+}
+
+// HasVerifiedEmail 检查用户是否有已验证的邮箱
+func (u *User) HasVerifiedEmail() bool {
+	return u.Email != nil && u.EmailVerifiedAt != nil
 }
 
 // SetPassword 设置密码（加密）
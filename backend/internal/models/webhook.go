@@ -0,0 +1,176 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookEvent Webhook事件类型
+type WebhookEvent string
+
+// Webhook事件类型常量
+const (
+	WebhookTaskCreated       WebhookEvent = "task.created"
+	WebhookTaskUpdated       WebhookEvent = "task.updated"
+	WebhookTaskStatusChanged WebhookEvent = "task.status_changed"
+	WebhookTaskDeleted       WebhookEvent = "task.deleted"
+	WebhookTaskRestored      WebhookEvent = "task.restored"
+	WebhookMilestoneCreated  WebhookEvent = "milestone.created"
+	WebhookMilestoneUpdated  WebhookEvent = "milestone.updated"
+	WebhookMilestoneDeleted  WebhookEvent = "milestone.deleted"
+	WebhookMilestoneRestored WebhookEvent = "milestone.restored"
+	WebhookPing              WebhookEvent = "ping" // 测试投递，始终发送，不需要订阅
+)
+
+// WebhookEvents 可订阅的全部事件
+var WebhookEvents = []WebhookEvent{
+	WebhookTaskCreated, WebhookTaskUpdated, WebhookTaskStatusChanged, WebhookTaskDeleted, WebhookTaskRestored,
+	WebhookMilestoneCreated, WebhookMilestoneUpdated, WebhookMilestoneDeleted, WebhookMilestoneRestored,
+}
+
+// IsValid 检查事件类型是否可以订阅
+func (e WebhookEvent) IsValid() bool {
+	for _, event := range WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEventsFor 返回操作记录对应的事件，任务状态变更时同时触发task.updated和task.status_changed
+func WebhookEventsFor(activity *Activity) []WebhookEvent {
+	events := []WebhookEvent{WebhookEvent(activity.EventName())}
+	if _, ok := activity.Changes["status"]; ok && activity.EntityType == EntityTask && activity.Action == ActivityUpdate {
+		events = append(events, WebhookTaskStatusChanged)
+	}
+	return events
+}
+
+// WebhookEventList 订阅的事件列表，以JSON格式存储
+type WebhookEventList []WebhookEvent
+
+// Value 实现driver.Valuer接口
+func (l WebhookEventList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan 实现sql.Scanner接口
+func (l *WebhookEventList) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*l = WebhookEventList{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("无法解析Webhook事件列表")
+	}
+	return json.Unmarshal(data, l)
+}
+
+// Has 检查是否订阅了指定事件
+func (l WebhookEventList) Has(event WebhookEvent) bool {
+	for _, e := range l {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook 项目的Webhook订阅，项目中的任务和里程碑发生订阅的事件时向URL发送签名的POST请求
+type Webhook struct {
+	ID        uint             `json:"id" gorm:"primarykey"`
+	ProjectID uint             `json:"project_id" gorm:"not null;index"`
+	URL       string           `json:"url" gorm:"size:500;not null"`
+	Secret    string           `json:"-" gorm:"size:100;not null"` // 签名密钥，只在创建和重置时返回
+	Events    WebhookEventList `json:"events" gorm:"type:text"`
+	Active    bool             `json:"active" gorm:"not null"`
+	CreatorID uint             `json:"creator_id" gorm:"not null"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// BeforeCreate 创建Webhook前的处理
+func (w *Webhook) BeforeCreate(tx *gorm.DB) error {
+	w.CreatedAt = time.Now()
+	w.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate 更新Webhook前的处理
+func (w *Webhook) BeforeUpdate(tx *gorm.DB) error {
+	w.UpdatedAt = time.Now()
+	return nil
+}
+
+// DefaultWebhookDeliveryRetentionDays 投递记录的默认保留天数，可通过环境变量WEBHOOK_DELIVERY_RETENTION_DAYS覆盖
+const DefaultWebhookDeliveryRetentionDays = 30
+
+// WebhookDeliveryRetention 已结束的投递记录的保留时长，超过后由定时任务删除
+func WebhookDeliveryRetention() time.Duration {
+	days := DefaultWebhookDeliveryRetentionDays
+	if n, err := strconv.Atoi(os.Getenv("WEBHOOK_DELIVERY_RETENTION_DAYS")); err == nil && n > 0 {
+		days = n
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// WebhookDeliveryStatus Webhook投递状态
+type WebhookDeliveryStatus string
+
+// Webhook投递状态常量
+const (
+	DeliveryPending   WebhookDeliveryStatus = "pending"   // 等待投递或等待重试
+	DeliverySucceeded WebhookDeliveryStatus = "succeeded" // 接收方返回2xx
+	DeliveryFailed    WebhookDeliveryStatus = "failed"    // 超过最大尝试次数
+)
+
+// WebhookDelivery Webhook投递记录，同时作为持久化的投递队列
+type WebhookDelivery struct {
+	ID             uint                  `json:"id" gorm:"primarykey"`
+	WebhookID      uint                  `json:"webhook_id" gorm:"not null;index"`
+	Event          WebhookEvent          `json:"event" gorm:"size:50;not null"`
+	Payload        json.RawMessage       `json:"payload" gorm:"type:text;not null"` // 发送的请求体
+	Status         WebhookDeliveryStatus `json:"status" gorm:"size:20;not null;index:idx_delivery_due"`
+	Attempts       int                   `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at" gorm:"index:idx_delivery_due"` // 下次尝试时间，投递结束后为空
+	LastAttemptAt  *time.Time            `json:"last_attempt_at"`
+	ResponseStatus int                   `json:"response_status"`       // 最近一次尝试的HTTP状态码，未收到响应时为0
+	Error          string                `json:"error" gorm:"size:500"` // 最近一次尝试失败的原因
+	DurationMs     int64                 `json:"duration_ms"`           // 最近一次尝试的耗时
+	CreatedAt      time.Time             `json:"created_at" gorm:"index"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+
+	Webhook *Webhook `json:"-" gorm:"foreignKey:WebhookID"`
+}
+
+// BeforeCreate 创建投递记录前的处理
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) error {
+	d.CreatedAt = time.Now()
+	d.UpdatedAt = time.Now()
+	return nil
+}
+
+// BeforeUpdate 更新投递记录前的处理
+func (d *WebhookDelivery) BeforeUpdate(tx *gorm.DB) error {
+	d.UpdatedAt = time.Now()
+	return nil
+}
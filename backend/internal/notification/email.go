@@ -0,0 +1,292 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"project_management/internal/mail"
+	"project_management/internal/models"
+	"project_management/internal/repository"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 摘要邮件包含的天数范围
+const (
+	dailyDigestDays  = 7
+	weeklyDigestDays = 14
+)
+
+// 默认在每天8点之后发送摘要，可通过环境变量DIGEST_HOUR覆盖
+const defaultDigestHour = 8
+
+// 退订链接的type参数
+const (
+	UnsubscribeDeadline = "deadline_soon"
+	UnsubscribeDigest   = "digest"
+	UnsubscribeAll      = "all"
+)
+
+// SendVerificationEmail 发送邮箱验证邮件
+func SendVerificationEmail(user *models.User, verification *models.EmailVerification) error {
+	var body strings.Builder
+	fmt.Fprintf(&body, "%s，你好：\n\n", displayName(user))
+	fmt.Fprintf(&body, "请打开以下链接验证你的邮箱 %s，链接%d小时内有效：\n\n", verification.Email, int(models.EmailVerificationTTL.Hours()))
+	fmt.Fprintf(&body, "%s/api/email/verify/%s\n\n", baseURL(), verification.Token)
+	body.WriteString("验证后你将收到任务截止提醒和任务摘要邮件。如果这不是你本人的操作，请忽略这封邮件。\n")
+
+	return mail.Enqueue(&models.EmailDelivery{
+		UserID:  user.ID,
+		Kind:    models.EmailVerify,
+		To:      verification.Email,
+		Subject: "验证你的邮箱",
+		Body:    body.String(),
+	})
+}
+
+// enqueueDeadlineEmail 发送任务截止提醒邮件
+func enqueueDeadlineEmail(user *models.User, task *models.Task, project string, key string) error {
+	unsubscribe := unsubscribeURL(user, UnsubscribeDeadline)
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "%s，你好：\n\n", displayName(user))
+	fmt.Fprintf(&body, "你负责的任务「%s」（项目「%s」）将于%s截止，当前状态为%s。\n",
+		task.Name, project, task.Deadline.Format("2006-01-02"), task.Status)
+	writeFooter(&body, "截止提醒", unsubscribe)
+
+	return mail.Enqueue(&models.EmailDelivery{
+		UserID:         user.ID,
+		Kind:           models.EmailDeadlineSoon,
+		To:             *user.Email,
+		Subject:        fmt.Sprintf("任务即将截止：%s", task.Name),
+		Body:           body.String(),
+		UnsubscribeURL: unsubscribe,
+		DedupKey:       key,
+	})
+}
+
+// SendDigests 每天DIGEST_HOUR点之后向邮箱已验证的用户发送任务和里程碑摘要：
+// 每日摘要每天一封，每周摘要在周一发送。没有待办任务和近期里程碑的用户不发送
+func SendDigests(ctx context.Context) error {
+	now := time.Now()
+	if now.Hour() < digestHour() {
+		return nil
+	}
+	today := models.OverdueCutoff()
+
+	users, err := repository.GetVerifiedEmailUsers()
+	if err != nil || len(users) == 0 {
+		return err
+	}
+	userIDs := make([]uint, len(users))
+	for i, user := range users {
+		userIDs[i] = user.ID
+	}
+	preferences, err := repository.GetNotificationPreferences(userIDs)
+	if err != nil {
+		return err
+	}
+
+	sent := 0
+	for i := range users {
+		if ctx.Err() != nil {
+			break
+		}
+		user := &users[i]
+
+		var key string
+		var days int
+		switch preferences[user.ID].EmailDigest {
+		case models.DigestDaily:
+			key, days = "digest:daily:"+today.Format("2006-01-02"), dailyDigestDays
+		case models.DigestWeekly:
+			if now.Weekday() != time.Monday {
+				continue
+			}
+			key, days = "digest:weekly:"+today.Format("2006-01-02"), weeklyDigestDays
+		default:
+			continue
+		}
+
+		queued, err := repository.EmailDeliveryExists(user.ID, key)
+		if err != nil {
+			return err
+		}
+		if queued {
+			continue
+		}
+
+		ok, err := enqueueDigest(user, today, today.AddDate(0, 0, days-1), key)
+		if err != nil {
+			return err
+		}
+		if ok {
+			sent++
+		}
+	}
+
+	if sent > 0 {
+		log.Printf("发送 %d 封任务摘要邮件", sent)
+	}
+	return nil
+}
+
+// enqueueDigest 汇总用户负责的未完成任务和参与项目的里程碑并发送摘要邮件，没有内容时不发送并返回false
+func enqueueDigest(user *models.User, from, to time.Time, key string) (bool, error) {
+	projectIDs, err := repository.GetUserProjectIDs(user.ID)
+	if err != nil {
+		return false, err
+	}
+
+	var open []models.TaskStatus
+	for _, status := range models.TaskStatuses {
+		if status != models.TaskStatusCompleted {
+			open = append(open, status)
+		}
+	}
+	tasks, err := repository.ExportTasks(repository.TaskFilter{
+		ProjectIDs: projectIDs,
+		AssigneeID: &user.ID,
+		Statuses:   open,
+		DeadlineTo: &to,
+	})
+	if err != nil {
+		return false, err
+	}
+	milestones, err := repository.ExportMilestones(repository.MilestoneFilter{
+		ProjectIDs: projectIDs,
+		DateFrom:   &from,
+		DateTo:     &to,
+	})
+	if err != nil {
+		return false, err
+	}
+	if len(tasks) == 0 && len(milestones) == 0 {
+		return false, nil
+	}
+
+	sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].Deadline.Before(tasks[j].Deadline) })
+	sort.SliceStable(milestones, func(i, j int) bool { return milestones[i].Date.Before(milestones[j].Date) })
+
+	var overdue, upcoming []*models.Task
+	for i := range tasks {
+		if tasks[i].Deadline.Before(from) {
+			overdue = append(overdue, &tasks[i])
+		} else {
+			upcoming = append(upcoming, &tasks[i])
+		}
+	}
+
+	projects := projectNames{}
+	unsubscribe := unsubscribeURL(user, UnsubscribeDigest)
+	period := from.Format("2006-01-02") + " 至 " + to.Format("2006-01-02")
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "%s，你好：\n\n以下是你在 %s 的任务和里程碑摘要。\n", displayName(user), period)
+	writeTasks := func(title string, tasks []*models.Task) error {
+		if len(tasks) == 0 {
+			return nil
+		}
+		fmt.Fprintf(&body, "\n%s（%d）：\n", title, len(tasks))
+		for _, task := range tasks {
+			project, err := projects.get(task.ProjectID)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(&body, "- [%s] %s，截止 %s，%s\n", project, task.Name, task.Deadline.Format("2006-01-02"), task.Status)
+		}
+		return nil
+	}
+	if err := writeTasks("已逾期的任务", overdue); err != nil {
+		return false, err
+	}
+	if err := writeTasks("即将截止的任务", upcoming); err != nil {
+		return false, err
+	}
+	if len(milestones) > 0 {
+		fmt.Fprintf(&body, "\n近期的里程碑（%d）：\n", len(milestones))
+		for _, milestone := range milestones {
+			project, err := projects.get(milestone.ProjectID)
+			if err != nil {
+				return false, err
+			}
+			fmt.Fprintf(&body, "- [%s] %s，%s\n", project, milestone.Title, milestone.Date.Format("2006-01-02"))
+		}
+	}
+	writeFooter(&body, "任务摘要", unsubscribe)
+
+	err = mail.Enqueue(&models.EmailDelivery{
+		UserID:         user.ID,
+		Kind:           models.EmailDigest,
+		To:             *user.Email,
+		Subject:        fmt.Sprintf("任务摘要：%d 个待办任务，%d 个近期里程碑", len(tasks), len(milestones)),
+		Body:           body.String(),
+		UnsubscribeURL: unsubscribe,
+		DedupKey:       key,
+	})
+	return err == nil, err
+}
+
+// writeFooter 写入邮件末尾的退订说明
+func writeFooter(body *strings.Builder, kind string, unsubscribe string) {
+	fmt.Fprintf(body, "\n--\n不想再收到%s邮件？打开以下链接退订：\n%s\n", kind, unsubscribe)
+}
+
+// unsubscribeURL 生成退订指定类型邮件的链接
+func unsubscribeURL(user *models.User, kind string) string {
+	if user.UnsubscribeToken == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s/api/email/unsubscribe/%s?type=%s", baseURL(), *user.UnsubscribeToken, kind)
+}
+
+// baseURL 邮件中链接使用的服务地址，由环境变量APP_BASE_URL配置
+func baseURL() string {
+	if url := strings.TrimRight(os.Getenv("APP_BASE_URL"), "/"); url != "" {
+		return url
+	}
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	return "http://localhost:" + port
+}
+
+// digestHour 读取每天开始发送摘要的时刻
+func digestHour() int {
+	if n, err := strconv.Atoi(os.Getenv("DIGEST_HOUR")); err == nil && n >= 0 && n <= 23 {
+		return n
+	}
+	return defaultDigestHour
+}
+
+// displayName 邮件中对用户的称呼
+func displayName(user *models.User) string {
+	if user.Name != "" {
+		return user.Name
+	}
+	return user.Username
+}
+
+// projectNames 按ID缓存项目名称，避免重复查询
+type projectNames map[uint]string
+
+// get 获取项目名称
+func (p projectNames) get(id uint) (string, error) {
+	if name, ok := p[id]; ok {
+		return name, nil
+	}
+	project, err := repository.GetProjectByID(id)
+	if err != nil {
+		return "", err
+	}
+	name := ""
+	if project != nil {
+		name = project.Name
+	}
+	p[id] = name
+	return name, nil
+}
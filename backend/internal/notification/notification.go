@@ -0,0 +1,237 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"project_management/internal/models"
+	"project_management/internal/repository"
+	"slices"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// 截止提醒扫描的最远范围，不小于models.MaxDeadlineReminderHours
+const deadlineLookahead = (models.MaxDeadlineReminderHours + 24) * time.Hour
+
+// 提及通知中评论内容的最大字符数
+const maxExcerptLength = 200
+
+// FromActivity 根据任务的操作记录生成通知：创建任务时通知负责人，修改任务时通知新增的负责人，
+// 状态变化时通知其余负责人。操作人自己不会收到通知，data为操作后的任务字段
+func FromActivity(activity *models.Activity, data models.Snapshot) error {
+	if activity.EntityType != models.EntityTask {
+		return nil
+	}
+	if activity.Action != models.ActivityCreate && activity.Action != models.ActivityUpdate {
+		return nil
+	}
+
+	name, _ := data["name"].(string)
+	assigneeIDs, _ := data["assignee_ids"].([]uint)
+
+	var assigned, others []uint
+	if activity.Action == models.ActivityCreate {
+		assigned = assigneeIDs
+	} else {
+		var previous []uint
+		if change, ok := activity.Changes["assignee_ids"]; ok {
+			previous, _ = change.Before.([]uint)
+		} else {
+			previous = assigneeIDs
+		}
+		for _, id := range assigneeIDs {
+			if slices.Contains(previous, id) {
+				others = append(others, id)
+			} else {
+				assigned = append(assigned, id)
+			}
+		}
+	}
+
+	var notifications []models.Notification
+	newNotification := func(userID uint, t models.NotificationType, title string) models.Notification {
+		return models.Notification{
+			UserID:     userID,
+			Type:       t,
+			ProjectID:  activity.ProjectID,
+			EntityType: activity.EntityType,
+			EntityID:   activity.EntityID,
+			ActorID:    activity.ActorID,
+			Title:      title,
+		}
+	}
+	for _, id := range assigned {
+		notifications = append(notifications, newNotification(id, models.NotificationAssigned,
+			fmt.Sprintf("你被分配了任务「%s」", name)))
+	}
+	if change, ok := activity.Changes["status"]; ok {
+		title := fmt.Sprintf("任务「%s」的状态从%v变为%v", name, change.Before, change.After)
+		for _, id := range others {
+			notifications = append(notifications, newNotification(id, models.NotificationStatusChanged, title))
+		}
+	}
+
+	return send(notifications, activity.ActorID)
+}
+
+// Mentioned 通知评论中新@提及的用户，评论作者自己不会收到通知
+func Mentioned(projectID uint, comment *models.Comment, users []models.User) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	var title string
+	switch comment.EntityType {
+	case models.EntityTask:
+		task, err := repository.GetTaskByID(comment.EntityID)
+		if err != nil {
+			return err
+		}
+		if task == nil {
+			return nil
+		}
+		title = fmt.Sprintf("在任务「%s」的评论中提到了你", task.Name)
+	case models.EntityMilestone:
+		milestone, err := repository.GetMilestoneByID(comment.EntityID)
+		if err != nil {
+			return err
+		}
+		if milestone == nil {
+			return nil
+		}
+		title = fmt.Sprintf("在里程碑「%s」的评论中提到了你", milestone.Title)
+	default:
+		return nil
+	}
+
+	content := comment.Content
+	if utf8.RuneCountInString(content) > maxExcerptLength {
+		content = string([]rune(content)[:maxExcerptLength]) + "…"
+	}
+
+	notifications := make([]models.Notification, len(users))
+	for i, user := range users {
+		notifications[i] = models.Notification{
+			UserID:     user.ID,
+			Type:       models.NotificationMentioned,
+			ProjectID:  projectID,
+			EntityType: comment.EntityType,
+			EntityID:   comment.EntityID,
+			CommentID:  &comment.ID,
+			ActorID:    &comment.AuthorID,
+			Title:      title,
+			Content:    content,
+		}
+	}
+	return send(notifications, &comment.AuthorID)
+}
+
+// DeadlineReminders 在任务截止前按负责人设置的提前小时数发送截止提醒，邮箱已验证的负责人同时收到提醒邮件，
+// 同一任务的同一截止日期只提醒一次
+func DeadlineReminders(ctx context.Context) error {
+	now := time.Now()
+	tasks, err := repository.GetUpcomingTasks(models.OverdueCutoff(), now.Add(deadlineLookahead))
+	if err != nil {
+		return err
+	}
+
+	var notifications []models.Notification
+	emails := 0
+	projects := projectNames{}
+	for i := range tasks {
+		task := &tasks[i]
+		if ctx.Err() != nil {
+			break
+		}
+		if len(task.Assignees) == 0 {
+			continue
+		}
+
+		userIDs := make([]uint, len(task.Assignees))
+		for i, user := range task.Assignees {
+			userIDs[i] = user.ID
+		}
+		preferences, err := repository.GetNotificationPreferences(userIDs)
+		if err != nil {
+			return err
+		}
+
+		deadline := task.Deadline.Format("2006-01-02")
+		key := "deadline:" + strconv.FormatUint(uint64(task.ID), 10) + ":" + deadline
+		remaining := task.DueAt().Sub(now)
+		for j := range task.Assignees {
+			user := &task.Assignees[j]
+			preference := preferences[user.ID]
+			if remaining > time.Duration(preference.DeadlineHours)*time.Hour {
+				continue
+			}
+
+			if preference.Allows(models.NotificationDeadlineSoon) {
+				sent, err := repository.NotificationSent(user.ID, key)
+				if err != nil {
+					return err
+				}
+				if !sent {
+					notifications = append(notifications, models.Notification{
+						UserID:     user.ID,
+						Type:       models.NotificationDeadlineSoon,
+						ProjectID:  task.ProjectID,
+						EntityType: models.EntityTask,
+						EntityID:   task.ID,
+						Title:      fmt.Sprintf("任务「%s」将于%s截止", task.Name, deadline),
+						DedupKey:   key,
+					})
+				}
+			}
+
+			if preference.EmailDeadlineSoon && user.HasVerifiedEmail() {
+				queued, err := repository.EmailDeliveryExists(user.ID, key)
+				if err != nil {
+					return err
+				}
+				if !queued {
+					project, err := projects.get(task.ProjectID)
+					if err != nil {
+						return err
+					}
+					if err := enqueueDeadlineEmail(user, task, project, key); err != nil {
+						return err
+					}
+					emails++
+				}
+			}
+		}
+	}
+
+	if len(notifications) > 0 || emails > 0 {
+		log.Printf("发送 %d 条任务截止提醒和 %d 封提醒邮件", len(notifications), emails)
+	}
+	return repository.CreateNotifications(notifications)
+}
+
+// send 按接收人的通知偏好过滤后保存通知，actorID对应的用户不会收到通知
+func send(notifications []models.Notification, actorID *uint) error {
+	var userIDs []uint
+	for _, n := range notifications {
+		if !slices.Contains(userIDs, n.UserID) {
+			userIDs = append(userIDs, n.UserID)
+		}
+	}
+	preferences, err := repository.GetNotificationPreferences(userIDs)
+	if err != nil {
+		return err
+	}
+
+	filtered := notifications[:0]
+	for _, n := range notifications {
+		if actorID != nil && n.UserID == *actorID {
+			continue
+		}
+		if preference := preferences[n.UserID]; preference.Allows(n.Type) {
+			filtered = append(filtered, n)
+		}
+	}
+	return repository.CreateNotifications(filtered)
+}
@@ -0,0 +1,252 @@
+package planning
+
+import (
+	"errors"
+	"project_management/internal/models"
+	"sort"
+	"time"
+)
+
+// ErrDependencyCycle 未完成的任务之间的依赖关系构成循环，无法计算排期
+var ErrDependencyCycle = errors.New("任务依赖存在循环")
+
+// node 排期计算中的任务节点，时间均为相对排期起点的天数，结束时间不含当天
+type node struct {
+	task         *models.Task
+	duration     int
+	due          int // 截止日期和里程碑日期中较早者的次日
+	deadline     int // 截止日期的次日
+	earlyStart   int
+	earlyFinish  int
+	lateStart    int
+	lateFinish   int
+	successors   []int
+	predecessors int
+}
+
+// Compute 按关键路径法计算项目排期。已完成的任务不参与计算，其余任务最早从start当天或计划开始日期开始，
+// 工期为预计工期，必须在所有阻塞任务完成后开始。最晚时间受全部任务的最早完工日期、
+// 任务截止日期和所属里程碑日期约束，总时差最小的任务即为关键任务。
+func Compute(projectID uint, start time.Time, tasks []models.Task, dependencies []models.TaskDependency, milestones []models.Milestone) (*models.ProjectSchedule, error) {
+	start = dateOf(start)
+	milestoneDue := make(map[uint]int, len(milestones))
+	for _, m := range milestones {
+		milestoneDue[m.ID] = days(start, m.Date) + 1
+	}
+
+	nodes := make([]*node, 0, len(tasks))
+	index := make(map[uint]int, len(tasks))
+	for i := range tasks {
+		task := &tasks[i]
+		if task.Status == models.TaskStatusCompleted {
+			continue
+		}
+		n := &node{task: task, duration: task.EstimatedDays, deadline: days(start, task.Deadline) + 1}
+		if n.duration < 1 {
+			n.duration = 1
+		}
+		if task.StartDate != nil {
+			if offset := days(start, *task.StartDate); offset > 0 {
+				n.earlyStart = offset
+			}
+		}
+		n.due = n.deadline
+		if task.MilestoneID != nil {
+			if due, ok := milestoneDue[*task.MilestoneID]; ok && due < n.due {
+				n.due = due
+			}
+		}
+		index[task.ID] = len(nodes)
+		nodes = append(nodes, n)
+	}
+
+	for _, dep := range dependencies {
+		from, ok := index[dep.BlockerID]
+		if !ok {
+			continue
+		}
+		to, ok := index[dep.BlockedID]
+		if !ok {
+			continue
+		}
+		nodes[from].successors = append(nodes[from].successors, to)
+		nodes[to].predecessors++
+	}
+
+	order, err := topologicalOrder(nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	// 正向计算最早开始和完成时间
+	finish := 0
+	for _, i := range order {
+		n := nodes[i]
+		n.earlyFinish = n.earlyStart + n.duration
+		if n.earlyFinish > finish {
+			finish = n.earlyFinish
+		}
+		for _, s := range n.successors {
+			if n.earlyFinish > nodes[s].earlyStart {
+				nodes[s].earlyStart = n.earlyFinish
+			}
+		}
+	}
+
+	// 反向计算最晚开始和完成时间
+	for k := len(order) - 1; k >= 0; k-- {
+		n := nodes[order[k]]
+		n.lateFinish = finish
+		if n.due < n.lateFinish {
+			n.lateFinish = n.due
+		}
+		for _, s := range n.successors {
+			if nodes[s].lateStart < n.lateFinish {
+				n.lateFinish = nodes[s].lateStart
+			}
+		}
+		n.lateStart = n.lateFinish - n.duration
+	}
+
+	schedule := &models.ProjectSchedule{
+		ProjectID:    projectID,
+		StartDate:    start,
+		CriticalPath: []uint{},
+		Tasks:        make([]models.TaskSchedule, 0, len(order)),
+		Milestones:   make([]models.MilestoneSchedule, 0, len(milestones)),
+	}
+	if len(nodes) == 0 {
+		for _, m := range milestones {
+			schedule.Milestones = append(schedule.Milestones, milestoneSchedule(start, m, nil))
+		}
+		return schedule, nil
+	}
+
+	finishDate := at(start, finish-1)
+	schedule.FinishDate = &finishDate
+
+	minSlack := nodes[0].lateStart - nodes[0].earlyStart
+	for _, n := range nodes {
+		if slack := n.lateStart - n.earlyStart; slack < minSlack {
+			minSlack = slack
+		}
+	}
+
+	// 按最早开始时间输出，便于按顺序展示关键路径
+	sort.SliceStable(order, func(a, b int) bool {
+		na, nb := nodes[order[a]], nodes[order[b]]
+		if na.earlyStart != nb.earlyStart {
+			return na.earlyStart < nb.earlyStart
+		}
+		return na.task.ID < nb.task.ID
+	})
+
+	byMilestone := make(map[uint][]*node)
+	for _, i := range order {
+		n := nodes[i]
+		slack := n.lateStart - n.earlyStart
+		critical := slack == minSlack
+		if critical {
+			schedule.CriticalPath = append(schedule.CriticalPath, n.task.ID)
+		}
+		schedule.Tasks = append(schedule.Tasks, models.TaskSchedule{
+			TaskID:         n.task.ID,
+			Name:           n.task.Name,
+			Status:         n.task.Status,
+			MilestoneID:    n.task.MilestoneID,
+			EstimatedDays:  n.duration,
+			Deadline:       n.task.Deadline,
+			EarliestStart:  at(start, n.earlyStart),
+			EarliestFinish: at(start, n.earlyFinish-1),
+			LatestStart:    at(start, n.lateStart),
+			LatestFinish:   at(start, n.lateFinish-1),
+			Slack:          slack,
+			Critical:       critical,
+			Late:           n.earlyFinish > n.deadline,
+		})
+		if n.task.MilestoneID != nil {
+			byMilestone[*n.task.MilestoneID] = append(byMilestone[*n.task.MilestoneID], n)
+		}
+	}
+
+	for _, m := range milestones {
+		schedule.Milestones = append(schedule.Milestones, milestoneSchedule(start, m, byMilestone[m.ID]))
+	}
+
+	return schedule, nil
+}
+
+// milestoneSchedule 根据里程碑关联的任务节点判断里程碑日期能否达成：
+// 任一任务的截止日期或最早完成日期晚于里程碑日期时视为无法达成
+func milestoneSchedule(start time.Time, m models.Milestone, nodes []*node) models.MilestoneSchedule {
+	result := models.MilestoneSchedule{
+		MilestoneID: m.ID,
+		Title:       m.Title,
+		Date:        m.Date,
+		LateTaskIDs: []uint{},
+	}
+
+	due := days(start, m.Date) + 1
+	projected := 0
+	for _, n := range nodes {
+		if n.earlyFinish > projected {
+			projected = n.earlyFinish
+		}
+		if n.deadline > due || n.earlyFinish > due {
+			result.LateTaskIDs = append(result.LateTaskIDs, n.task.ID)
+		}
+	}
+	if len(nodes) > 0 {
+		date := at(start, projected-1)
+		result.ProjectedDate = &date
+	}
+	result.AtRisk = len(result.LateTaskIDs) > 0
+
+	return result
+}
+
+// topologicalOrder 按依赖关系对任务排序，阻塞任务排在被阻塞任务之前
+func topologicalOrder(nodes []*node) ([]int, error) {
+	remaining := make([]int, len(nodes))
+	queue := make([]int, 0, len(nodes))
+	for i, n := range nodes {
+		remaining[i] = n.predecessors
+		if n.predecessors == 0 {
+			queue = append(queue, i)
+		}
+	}
+
+	order := make([]int, 0, len(nodes))
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		order = append(order, i)
+		for _, s := range nodes[i].successors {
+			remaining[s]--
+			if remaining[s] == 0 {
+				queue = append(queue, s)
+			}
+		}
+	}
+
+	if len(order) != len(nodes) {
+		return nil, ErrDependencyCycle
+	}
+	return order, nil
+}
+
+// dateOf 返回时间所在日期的零点（UTC），截止日期和里程碑日期均按UTC日期存储
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// days 返回从start到t所在日期的天数
+func days(start, t time.Time) int {
+	t = t.UTC()
+	return int(dateOf(t).Sub(start).Hours() / 24)
+}
+
+// at 返回start之后第n天的日期
+func at(start time.Time, n int) time.Time {
+	return start.AddDate(0, 0, n)
+}
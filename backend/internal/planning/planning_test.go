@@ -0,0 +1,242 @@
+package planning
+
+import (
+	"errors"
+	"project_management/internal/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+
+// day 返回排期起点之后第n天的日期
+func day(n int) time.Time {
+	return start.AddDate(0, 0, n)
+}
+
+// task 创建状态为待处理、截止日期足够晚的任务
+func task(id uint, days int) models.Task {
+	return models.Task{ID: id, Name: "任务", Status: models.TaskStatusPending, EstimatedDays: days, Deadline: day(365)}
+}
+
+func TestComputeCriticalPath(t *testing.T) {
+	// 1 -> 2 -> 4，1 -> 3 -> 4，其中经过2的路径更长
+	tasks := []models.Task{task(1, 2), task(2, 3), task(3, 1), task(4, 1)}
+	dependencies := []models.TaskDependency{
+		{BlockerID: 1, BlockedID: 2},
+		{BlockerID: 1, BlockedID: 3},
+		{BlockerID: 2, BlockedID: 4},
+		{BlockerID: 3, BlockedID: 4},
+	}
+
+	schedule, err := Compute(7, start, tasks, dependencies, nil)
+	if err != nil {
+		t.Fatalf("Compute() error = %v", err)
+	}
+
+	if want := []uint{1, 2, 4}; !reflect.DeepEqual(schedule.CriticalPath, want) {
+		t.Errorf("CriticalPath = %v, want %v", schedule.CriticalPath, want)
+	}
+	if schedule.FinishDate == nil || !schedule.FinishDate.Equal(day(5)) {
+		t.Errorf("FinishDate = %v, want %v", schedule.FinishDate, day(5))
+	}
+
+	tests := []struct {
+		id                           uint
+		earliestStart, latestStart   int
+		earliestFinish, latestFinish int
+		slack                        int
+		critical                     bool
+	}{
+		{1, 0, 0, 1, 1, 0, true},
+		{2, 2, 2, 4, 4, 0, true},
+		{3, 2, 4, 2, 4, 2, false},
+		{4, 5, 5, 5, 5, 0, true},
+	}
+	got := make(map[uint]models.TaskSchedule, len(schedule.Tasks))
+	for _, s := range schedule.Tasks {
+		got[s.TaskID] = s
+	}
+	for _, tt := range tests {
+		s, ok := got[tt.id]
+		if !ok {
+			t.Errorf("task %d missing from schedule", tt.id)
+			continue
+		}
+		if !s.EarliestStart.Equal(day(tt.earliestStart)) || !s.LatestStart.Equal(day(tt.latestStart)) {
+			t.Errorf("task %d start = %v..%v, want %v..%v", tt.id, s.EarliestStart, s.LatestStart, day(tt.earliestStart), day(tt.latestStart))
+		}
+		if !s.EarliestFinish.Equal(day(tt.earliestFinish)) || !s.LatestFinish.Equal(day(tt.latestFinish)) {
+			t.Errorf("task %d finish = %v..%v, want %v..%v", tt.id, s.EarliestFinish, s.LatestFinish, day(tt.earliestFinish), day(tt.latestFinish))
+		}
+		if s.Slack != tt.slack || s.Critical != tt.critical {
+			t.Errorf("task %d slack = %d critical = %v, want %d %v", tt.id, s.Slack, s.Critical, tt.slack, tt.critical)
+		}
+	}
+}
+
+func TestComputeSlack(t *testing.T) {
+	completed := task(9, 30)
+	completed.Status = models.TaskStatusCompleted
+	milestoneID := uint(1)
+
+	tests := []struct {
+		name       string
+		task       models.Task
+		milestones []models.Milestone
+		wantSlack  int
+		wantLate   bool
+	}{
+		{
+			name:      "截止日期宽裕",
+			task:      task(1, 3),
+			wantSlack: 0,
+		},
+		{
+			name:      "无法按截止日期完成时时差为负",
+			task:      models.Task{ID: 1, Status: models.TaskStatusPending, EstimatedDays: 3, Deadline: day(0)},
+			wantSlack: -2,
+			wantLate:  true,
+		},
+		{
+			name:      "未填写预计工期按一天计算",
+			task:      models.Task{ID: 1, Status: models.TaskStatusPending, Deadline: day(0)},
+			wantSlack: 0,
+		},
+		{
+			name:      "计划开始日期推迟最早开始时间",
+			task:      models.Task{ID: 1, Status: models.TaskStatusPending, EstimatedDays: 2, StartDate: ptr(day(2)), Deadline: day(3)},
+			wantSlack: 0,
+		},
+		{
+			name:       "里程碑日期早于截止日期时按里程碑日期计算",
+			task:       models.Task{ID: 1, Status: models.TaskStatusPending, EstimatedDays: 2, MilestoneID: &milestoneID, Deadline: day(10)},
+			milestones: []models.Milestone{{ID: milestoneID, Date: day(0)}},
+			wantSlack:  -1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 已完成的任务不参与计算，不会推迟被阻塞的任务
+			tasks := []models.Task{completed, tt.task}
+			dependencies := []models.TaskDependency{{BlockerID: completed.ID, BlockedID: tt.task.ID}}
+
+			schedule, err := Compute(7, start, tasks, dependencies, tt.milestones)
+			if err != nil {
+				t.Fatalf("Compute() error = %v", err)
+			}
+			if len(schedule.Tasks) != 1 {
+				t.Fatalf("got %d tasks, want 1", len(schedule.Tasks))
+			}
+			s := schedule.Tasks[0]
+			if s.Slack != tt.wantSlack {
+				t.Errorf("Slack = %d, want %d", s.Slack, tt.wantSlack)
+			}
+			if s.Late != tt.wantLate {
+				t.Errorf("Late = %v, want %v", s.Late, tt.wantLate)
+			}
+			if !s.Critical {
+				t.Errorf("the only task is not critical")
+			}
+		})
+	}
+}
+
+func TestComputeMilestoneAtRisk(t *testing.T) {
+	onTime, late := uint(1), uint(2)
+	first, second := task(1, 2), task(2, 2)
+	first.MilestoneID, first.Deadline = &onTime, day(1)
+	second.MilestoneID, second.Deadline = &late, day(2)
+	milestones := []models.Milestone{
+		{ID: onTime, Date: day(1)},
+		{ID: late, Date: day(2)},
+		{ID: 3, Date: day(0)},
+	}
+
+	schedule, err := Compute(7, start, []models.Task{first, second}, []models.TaskDependency{{BlockerID: 1, BlockedID: 2}}, milestones)
+	if err != nil {
+		t.Fatalf("Compute() error = %v", err)
+	}
+
+	tests := []struct {
+		atRisk    bool
+		lateTasks []uint
+		projected *time.Time
+	}{
+		{false, []uint{}, ptr(day(1))},
+		{true, []uint{2}, ptr(day(3))},
+		{false, []uint{}, nil},
+	}
+	for i, tt := range tests {
+		m := schedule.Milestones[i]
+		if m.AtRisk != tt.atRisk || !reflect.DeepEqual(m.LateTaskIDs, tt.lateTasks) {
+			t.Errorf("milestone %d at risk = %v late tasks = %v, want %v %v", m.MilestoneID, m.AtRisk, m.LateTaskIDs, tt.atRisk, tt.lateTasks)
+		}
+		if (m.ProjectedDate == nil) != (tt.projected == nil) || (m.ProjectedDate != nil && !m.ProjectedDate.Equal(*tt.projected)) {
+			t.Errorf("milestone %d projected = %v, want %v", m.MilestoneID, m.ProjectedDate, tt.projected)
+		}
+	}
+}
+
+func TestComputeDependencyCycle(t *testing.T) {
+	completed := task(3, 1)
+	completed.Status = models.TaskStatusCompleted
+	tasks := []models.Task{task(1, 1), task(2, 1), completed}
+
+	tests := []struct {
+		name         string
+		dependencies []models.TaskDependency
+		wantErr      error
+	}{
+		{
+			name:         "两个任务互相阻塞",
+			dependencies: []models.TaskDependency{{BlockerID: 1, BlockedID: 2}, {BlockerID: 2, BlockedID: 1}},
+			wantErr:      ErrDependencyCycle,
+		},
+		{
+			name:         "任务阻塞自身",
+			dependencies: []models.TaskDependency{{BlockerID: 1, BlockedID: 1}},
+			wantErr:      ErrDependencyCycle,
+		},
+		{
+			name:         "循环经过已完成的任务时忽略",
+			dependencies: []models.TaskDependency{{BlockerID: 1, BlockedID: 3}, {BlockerID: 3, BlockedID: 1}},
+		},
+		{
+			name:         "依赖不存在的任务时忽略",
+			dependencies: []models.TaskDependency{{BlockerID: 1, BlockedID: 4}, {BlockerID: 4, BlockedID: 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compute(7, start, tasks, tt.dependencies, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Compute() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestComputeWithoutOpenTasks(t *testing.T) {
+	completed := task(1, 1)
+	completed.Status = models.TaskStatusCompleted
+
+	schedule, err := Compute(7, start.Add(15*time.Hour), []models.Task{completed}, nil, []models.Milestone{{ID: 1, Date: day(1)}})
+	if err != nil {
+		t.Fatalf("Compute() error = %v", err)
+	}
+	if !schedule.StartDate.Equal(start) {
+		t.Errorf("StartDate = %v, want %v", schedule.StartDate, start)
+	}
+	if schedule.FinishDate != nil || len(schedule.Tasks) != 0 || len(schedule.CriticalPath) != 0 {
+		t.Errorf("got finish date %v, %d tasks and critical path %v, want none", schedule.FinishDate, len(schedule.Tasks), schedule.CriticalPath)
+	}
+	if len(schedule.Milestones) != 1 || schedule.Milestones[0].AtRisk || schedule.Milestones[0].ProjectedDate != nil {
+		t.Errorf("Milestones = %+v, want one milestone without projection", schedule.Milestones)
+	}
+}
+
+func ptr(t time.Time) *time.Time {
+	return &t
+}
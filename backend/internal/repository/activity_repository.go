@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"project_management/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CreateActivity 创建操作记录
+func CreateActivity(activity *models.Activity) error {
+	return DB.Create(activity).Error
+}
+
+// CreateActivities 批量创建操作记录
+func CreateActivities(activities []models.Activity) error {
+	if len(activities) == 0 {
+		return nil
+	}
+	return DB.Create(&activities).Error
+}
+
+// GetActivitiesAfter 按ID顺序获取项目中ID大于afterID的操作记录，最多limit条
+func GetActivitiesAfter(projectIDs []uint, afterID uint, limit int) ([]models.Activity, error) {
+	activities := []models.Activity{}
+	if len(projectIDs) == 0 {
+		return activities, nil
+	}
+	err := DB.Where("project_id IN ? AND id > ?", projectIDs, afterID).
+		Order("id asc").
+		Limit(limit).
+		Find(&activities).Error
+	return activities, err
+}
+
+// ActivityFilter 操作记录列表的筛选条件
+type ActivityFilter struct {
+	ProjectIDs []uint                  // 限定的项目范围，为空时不返回任何记录
+	EntityType models.EntityType       // 实体类型
+	EntityID   *uint                   // 实体ID，需与EntityType一起使用
+	ActorID    *uint                   // 操作人ID
+	Actions    []models.ActivityAction // 操作类型
+	From       *time.Time              // 操作时间下限（含）
+	To         *time.Time              // 操作时间上限（不含）
+}
+
+// activitySortColumns 操作记录列表允许排序的字段
+var activitySortColumns = map[string]string{
+	"id":         "id",
+	"created_at": "created_at",
+}
+
+// ListActivities 按条件分页查询操作记录，返回当前页的记录和符合条件的总数
+func ListActivities(filter ActivityFilter, opts ListOptions) ([]models.Activity, int64, error) {
+	activities := []models.Activity{}
+	if len(filter.ProjectIDs) == 0 {
+		return activities, 0, nil
+	}
+
+	query := DB.Model(&models.Activity{}).Where("project_id IN ?", filter.ProjectIDs)
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != nil {
+		query = query.Where("entity_id = ?", *filter.EntityID)
+	}
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if len(filter.Actions) > 0 {
+		query = query.Where("action IN ?", filter.Actions)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	// 同一查询条件分别用于统计总数和分页查询
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	paged, err := paginate(query, opts, activitySortColumns, "created_at desc, id desc")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = paged.Preload("Actor").Find(&activities).Error
+	return activities, total, err
+}
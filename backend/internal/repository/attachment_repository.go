@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"errors"
+	"project_management/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CreateAttachment 创建附件记录
+func CreateAttachment(attachment *models.Attachment) error {
+	return DB.Create(attachment).Error
+}
+
+// GetAttachmentByID 通过ID获取附件记录
+func GetAttachmentByID(id uint) (*models.Attachment, error) {
+	var attachment models.Attachment
+	err := DB.Preload("Uploader").First(&attachment, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// GetTaskAttachments 获取任务的全部附件，按上传时间排序
+func GetTaskAttachments(taskID uint) ([]models.Attachment, error) {
+	attachments := []models.Attachment{}
+	err := DB.Preload("Uploader").Where("task_id = ?", taskID).Order("created_at asc, id asc").Find(&attachments).Error
+	return attachments, err
+}
+
+// DeleteAttachment 删除附件记录
+func DeleteAttachment(id uint) error {
+	return DB.Delete(&models.Attachment{}, id).Error
+}
+
+// GetTaskAttachmentKeys 获取任务附件在存储后端中的对象键
+func GetTaskAttachmentKeys(taskIDs []uint) ([]string, error) {
+	var keys []string
+	if len(taskIDs) == 0 {
+		return keys, nil
+	}
+	err := DB.Model(&models.Attachment{}).Where("task_id IN ?", taskIDs).Pluck("storage_key", &keys).Error
+	return keys, err
+}
+
+// GetProjectAttachmentKeys 获取项目下所有任务附件在存储后端中的对象键
+func GetProjectAttachmentKeys(projectID uint) ([]string, error) {
+	var keys []string
+	err := DB.Model(&models.Attachment{}).
+		Where("task_id IN (?)", DB.Unscoped().Model(&models.Task{}).Select("id").Where("project_id = ?", projectID)).
+		Pluck("storage_key", &keys).Error
+	return keys, err
+}
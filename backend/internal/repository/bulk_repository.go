@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"errors"
+	"project_management/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// errBulkRollback 用于在全部成功模式下回滚批量操作的事务
+var errBulkRollback = errors.New("批量操作已回滚")
+
+// BulkTaskChange 批量操作中对单个任务的修改
+type BulkTaskChange struct {
+	Task    *models.Task // 待保存的任务，Delete为true时只使用其ID
+	Delete  bool         // 将任务移入回收站
+	Cascade bool         // 删除时一并删除全部下级任务
+}
+
+// ApplyBulkTaskChanges 在同一事务中依次执行批量修改，每个修改使用独立的保存点，返回每个修改的错误。
+// 只有版本冲突会作为单个修改的错误返回，其他数据库错误会回滚整个事务并直接返回。
+// atomic为false时失败的修改单独回滚，其余修改照常提交；atomic为true时任一修改失败都会回滚全部修改
+func ApplyBulkTaskChanges(changes []BulkTaskChange, atomic bool) ([]error, error) {
+	errs := make([]error, len(changes))
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		failed := false
+		for i, change := range changes {
+			errs[i] = tx.Transaction(func(tx *gorm.DB) error {
+				if change.Delete {
+					return deleteTask(tx, change.Task.ID, change.Cascade)
+				}
+				return updateTask(tx, change.Task)
+			})
+			if errs[i] == nil {
+				continue
+			}
+			if !errors.Is(errs[i], ErrVersionConflict) {
+				return errs[i]
+			}
+			failed = true
+		}
+		if atomic && failed {
+			return errBulkRollback
+		}
+		return nil
+	})
+	if errors.Is(err, errBulkRollback) {
+		err = nil
+	}
+	return errs, err
+}
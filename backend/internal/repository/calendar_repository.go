@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"errors"
+	"project_management/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GetUserByCalendarToken 通过日历订阅令牌获取用户
+func GetUserByCalendarToken(token string) (*models.User, error) {
+	var user models.User
+	err := DB.Where("calendar_token = ?", token).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SetUserCalendarToken 设置用户的日历订阅令牌，token为空表示关闭订阅
+func SetUserCalendarToken(userID uint, token *string) error {
+	return DB.Model(&models.User{}).Where("id = ?", userID).Update("calendar_token", token).Error
+}
+
+// GetCalendarTasks 获取项目中截止日期不早于since的任务，assigneeID不为空时只返回该用户负责的任务
+func GetCalendarTasks(projectIDs []uint, assigneeID *uint, since time.Time) ([]models.Task, error) {
+	tasks := []models.Task{}
+	if len(projectIDs) == 0 {
+		return tasks, nil
+	}
+	err := filterTasks(TaskFilter{ProjectIDs: projectIDs, AssigneeID: assigneeID, DeadlineFrom: &since}).
+		Preload("Assignees").
+		Order("deadline, id").
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// GetCalendarMilestones 获取项目中日期不早于since的里程碑
+func GetCalendarMilestones(projectIDs []uint, since time.Time) ([]models.Milestone, error) {
+	milestones := []models.Milestone{}
+	if len(projectIDs) == 0 {
+		return milestones, nil
+	}
+	err := filterMilestones(MilestoneFilter{ProjectIDs: projectIDs, DateFrom: &since}).
+		Order("date, id").
+		Find(&milestones).Error
+	return milestones, err
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"errors"
+	"project_management/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CreateComment 创建评论，同时写入@提及的用户
+func CreateComment(comment *models.Comment) error {
+	return DB.Omit("Mentions.*").Create(comment).Error
+}
+
+// GetCommentByID 通过ID获取评论
+func GetCommentByID(id uint) (*models.Comment, error) {
+	var comment models.Comment
+	err := DB.Preload("Author").Preload("Mentions").First(&comment, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// commentSortColumns 评论列表允许排序的字段
+var commentSortColumns = map[string]string{
+	"id":         "id",
+	"created_at": "created_at",
+}
+
+// ListComments 分页查询实体下的顶层评论，每条评论附带其下的全部回复
+func ListComments(entityType models.EntityType, entityID uint, opts ListOptions) ([]models.Comment, int64, error) {
+	comments := []models.Comment{}
+	query := DB.Model(&models.Comment{}).
+		Where("entity_type = ? AND entity_id = ? AND parent_id IS NULL", entityType, entityID).
+		Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	paged, err := paginate(query, opts, commentSortColumns, "created_at asc, id asc")
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := paged.Preload("Author").Preload("Mentions").Find(&comments).Error; err != nil {
+		return nil, 0, err
+	}
+	if len(comments) == 0 {
+		return comments, total, nil
+	}
+
+	// 回复数量有限，一次取出该实体下的全部回复后在内存中组装回复串
+	var replies []models.Comment
+	err = DB.Where("entity_type = ? AND entity_id = ? AND parent_id IS NOT NULL", entityType, entityID).
+		Order("created_at asc, id asc").
+		Preload("Author").Preload("Mentions").
+		Find(&replies).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	children := make(map[uint][]models.Comment)
+	for _, reply := range replies {
+		children[*reply.ParentID] = append(children[*reply.ParentID], reply)
+	}
+	for i := range comments {
+		attachReplies(&comments[i], children)
+	}
+	return comments, total, nil
+}
+
+// attachReplies 递归地为评论填充回复
+func attachReplies(comment *models.Comment, children map[uint][]models.Comment) {
+	replies := children[comment.ID]
+	for i := range replies {
+		attachReplies(&replies[i], children)
+	}
+	comment.Replies = replies
+}
+
+// UpdateComment 更新评论内容，并替换@提及的用户
+func UpdateComment(comment *models.Comment) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Mentions").Save(comment).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("comment_id = ?", comment.ID).Delete(&models.CommentMention{}).Error; err != nil {
+			return err
+		}
+		for _, user := range comment.Mentions {
+			if err := tx.Create(&models.CommentMention{CommentID: comment.ID, UserID: user.ID}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteComment 删除评论。仍有回复的评论保留为占位并清空内容，
+// 删除后没有剩余回复的已删除上级评论会一并清理
+func DeleteComment(id uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		for {
+			var comment models.Comment
+			if err := tx.First(&comment, id).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("comment_id = ?", comment.ID).Delete(&models.CommentMention{}).Error; err != nil {
+				return err
+			}
+
+			var replies int64
+			if err := tx.Model(&models.Comment{}).Where("parent_id = ?", comment.ID).Count(&replies).Error; err != nil {
+				return err
+			}
+			if replies > 0 {
+				return tx.Model(&comment).Updates(map[string]interface{}{"content": "", "deleted": true}).Error
+			}
+			if err := tx.Delete(&comment).Error; err != nil {
+				return err
+			}
+
+			// 上级评论已删除且不再有回复时继续清理
+			if comment.ParentID == nil {
+				return nil
+			}
+			var parent models.Comment
+			if err := tx.First(&parent, *comment.ParentID).Error; err != nil {
+				return err
+			}
+			if !parent.Deleted {
+				return nil
+			}
+			id = parent.ID
+		}
+	})
+}
+
+// deleteEntityComments 删除实体下的全部评论及其@提及记录，entityIDs可以是ID列表或子查询
+func deleteEntityComments(tx *gorm.DB, entityType models.EntityType, entityIDs interface{}) error {
+	commentIDs := tx.Model(&models.Comment{}).Select("id").Where("entity_type = ? AND entity_id IN (?)", entityType, entityIDs)
+	if err := tx.Where("comment_id IN (?)", commentIDs).Delete(&models.CommentMention{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("entity_type = ? AND entity_id IN (?)", entityType, entityIDs).Delete(&models.Comment{}).Error
+}
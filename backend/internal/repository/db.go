@@ -1,9 +1,12 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"regexp"
+	"strings"
 
 	"project_management/internal/models"
 
@@ -34,15 +37,15 @@ func InitDB() {
 		dbPassword := os.Getenv("DB_PASSWORD")
 		dbName := os.Getenv("DB_NAME")
 		dbCharset := os.Getenv("DB_CHARSET")
-		
+
 		if dbCharset == "" {
 			dbCharset = "utf8mb4"
 		}
-		
+
 		// 构建DSN (Data Source Name)
 		dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=%s&parseTime=true&loc=Local",
 			dbUser, dbPassword, dbHost, dbPort, dbName, dbCharset)
-			
+
 		// 连接MySQL
 		db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
 			Logger: logger.Default.LogMode(logger.Info),
@@ -50,7 +53,7 @@ func InitDB() {
 		if err != nil {
 			log.Fatalf("无法连接到MySQL数据库: %v", err)
 		}
-		
+
 		DB = db
 	} else {
 		// SQLite连接(保留原代码作为备选)
@@ -58,32 +61,209 @@ func InitDB() {
 		if dbPath == "" {
 			dbPath = "./database.db"
 		}
-		
+
 		db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{
 			Logger: logger.Default.LogMode(logger.Info),
 		})
 		if err != nil {
 			log.Fatalf("无法连接到SQLite数据库: %v", err)
 		}
-		
+
 		DB = db
 	}
 
+	// 使用自定义的任务负责人关联表
+	if err := DB.SetupJoinTable(&models.Task{}, "Assignees", &models.TaskAssignee{}); err != nil {
+		log.Fatalf("设置任务负责人关联表失败: %v", err)
+	}
+	if err := DB.SetupJoinTable(&models.Comment{}, "Mentions", &models.CommentMention{}); err != nil {
+		log.Fatalf("设置评论提及关联表失败: %v", err)
+	}
+
 	// 自动迁移模型
 	err = DB.AutoMigrate(
 		&models.User{},
+		&models.Project{},
+		&models.ProjectMember{},
 		&models.Task{},
 		&models.Milestone{},
 		&models.RefreshToken{},
+		&models.Activity{},
+		&models.Comment{},
+		&models.Attachment{},
+		&models.TaskDependency{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.EmailVerification{},
+		&models.EmailDelivery{},
 	)
 	if err != nil {
 		log.Fatalf("自动迁移失败: %v", err)
 	}
 
+	// 将旧版本中未归属项目的任务和里程碑迁移到默认项目
+	if err := migrateDefaultProject(); err != nil {
+		log.Fatalf("迁移默认项目失败: %v", err)
+	}
+
+	// 为没有成员的项目补充成员记录
+	if err := migrateProjectMembers(); err != nil {
+		log.Fatalf("迁移项目成员失败: %v", err)
+	}
+
+	// 将旧版本的文本负责人迁移为用户关联
+	if err := migrateLegacyAssignees(); err != nil {
+		log.Fatalf("迁移任务负责人失败: %v", err)
+	}
+
 	log.Println("数据库初始化完成")
 }
 
+// migrateDefaultProject 为没有项目的历史任务和里程碑创建默认项目
+func migrateDefaultProject() error {
+	var orphanTasks, orphanMilestones int64
+	if err := DB.Model(&models.Task{}).Where("project_id = 0 OR project_id IS NULL").Count(&orphanTasks).Error; err != nil {
+		return err
+	}
+	if err := DB.Model(&models.Milestone{}).Where("project_id = 0 OR project_id IS NULL").Count(&orphanMilestones).Error; err != nil {
+		return err
+	}
+	if orphanTasks == 0 && orphanMilestones == 0 {
+		return nil
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		project := &models.Project{
+			Name:        "默认项目",
+			Description: "系统升级时自动创建，包含升级前的任务和里程碑",
+		}
+		if err := tx.Create(project).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Task{}).Where("project_id = 0 OR project_id IS NULL").
+			Update("project_id", project.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Milestone{}).Where("project_id = 0 OR project_id IS NULL").
+			Update("project_id", project.ID).Error; err != nil {
+			return err
+		}
+		log.Printf("已将 %d 个任务和 %d 个里程碑迁移到默认项目", orphanTasks, orphanMilestones)
+		return nil
+	})
+}
+
 // GetDB 获取数据库连接
 func GetDB() *gorm.DB {
 	return DB
-} 
\ No newline at end of file
+}
+
+// migrateProjectMembers 为没有任何成员的项目补充成员记录。
+// 有创建者的项目将创建者设为所有者；系统创建的默认项目则把最早注册的用户设为所有者，
+// 其余用户设为普通成员，以保证升级前的数据仍然可以访问。
+func migrateProjectMembers() error {
+	var projects []models.Project
+	err := DB.Where("id NOT IN (?)", DB.Model(&models.ProjectMember{}).Select("project_id")).Find(&projects).Error
+	if err != nil {
+		return err
+	}
+
+	for _, project := range projects {
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			if project.OwnerID != 0 {
+				return tx.Create(&models.ProjectMember{
+					ProjectID: project.ID,
+					UserID:    project.OwnerID,
+					Role:      models.ProjectRoleOwner,
+				}).Error
+			}
+
+			var users []models.User
+			if err := tx.Order("id asc").Find(&users).Error; err != nil {
+				return err
+			}
+			if len(users) == 0 {
+				return nil
+			}
+
+			for i, user := range users {
+				role := models.ProjectRoleMember
+				if i == 0 {
+					role = models.ProjectRoleOwner
+				}
+				member := &models.ProjectMember{ProjectID: project.ID, UserID: user.ID, Role: role}
+				if err := tx.Create(member).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Model(&models.Project{}).Where("id = ?", project.ID).Update("owner_id", users[0].ID).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// legacyAssigneeSeparator 旧版本负责人文本中多个负责人之间可能使用的分隔符
+var legacyAssigneeSeparator = regexp.MustCompile(`[,，、;；/]`)
+
+// migrateLegacyAssignees 将旧版本tasks.assignee中的文本按用户名或姓名匹配到用户。
+// 匹配成功的负责人写入task_assignees并从文本中移除，未能匹配的文本原样保留以便人工处理。
+func migrateLegacyAssignees() error {
+	if !DB.Migrator().HasColumn(&models.Task{}, "assignee") {
+		return nil
+	}
+
+	var rows []struct {
+		ID       uint
+		Assignee string
+	}
+	err := DB.Table("tasks").Select("id, assignee").
+		Where("assignee IS NOT NULL AND assignee <> ''").Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		var unmatched []string
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			for _, name := range legacyAssigneeSeparator.Split(row.Assignee, -1) {
+				name = strings.TrimSpace(name)
+				if name == "" {
+					continue
+				}
+
+				// 优先按用户名匹配，其次按姓名匹配
+				var user models.User
+				err := tx.Where("username = ?", name).First(&user).Error
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					err = tx.Where("name = ?", name).Order("id asc").First(&user).Error
+				}
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					unmatched = append(unmatched, name)
+					continue
+				}
+				if err != nil {
+					return err
+				}
+
+				assignee := models.TaskAssignee{TaskID: row.ID, UserID: user.ID}
+				if err := tx.Where(&assignee).FirstOrCreate(&assignee).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Table("tasks").Where("id = ?", row.ID).
+				Update("assignee", strings.Join(unmatched, ",")).Error
+		})
+		if err != nil {
+			return err
+		}
+		if len(unmatched) > 0 {
+			log.Printf("任务 %d 的负责人 %v 未能匹配到用户，已保留在tasks.assignee中", row.ID, unmatched)
+		}
+	}
+	return nil
+}
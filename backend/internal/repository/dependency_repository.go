@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"errors"
+	"project_management/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CreateTaskDependency 创建任务依赖关系
+func CreateTaskDependency(dependency *models.TaskDependency) error {
+	return DB.Create(dependency).Error
+}
+
+// GetTaskDependency 获取两个任务之间的依赖关系，任一任务在回收站中时返回nil
+func GetTaskDependency(blockerID, blockedID uint) (*models.TaskDependency, error) {
+	var dependency models.TaskDependency
+	err := liveDependencies().Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).First(&dependency).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &dependency, nil
+}
+
+// DeleteTaskDependency 删除任务依赖关系
+func DeleteTaskDependency(blockerID, blockedID uint) error {
+	return DB.Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).Delete(&models.TaskDependency{}).Error
+}
+
+// GetTaskBlockers 获取阻塞该任务的任务
+func GetTaskBlockers(taskID uint) ([]models.Task, error) {
+	tasks := []models.Task{}
+	err := DB.Preload("Assignees").
+		Where("id IN (?)", DB.Model(&models.TaskDependency{}).Select("blocker_id").Where("blocked_id = ?", taskID)).
+		Order("deadline asc, id asc").
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// GetTaskBlocking 获取被该任务阻塞的任务
+func GetTaskBlocking(taskID uint) ([]models.Task, error) {
+	tasks := []models.Task{}
+	err := DB.Preload("Assignees").
+		Where("id IN (?)", DB.Model(&models.TaskDependency{}).Select("blocked_id").Where("blocker_id = ?", taskID)).
+		Order("deadline asc, id asc").
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// GetOpenBlockers 获取阻塞该任务且尚未完成的任务
+func GetOpenBlockers(taskID uint) ([]models.Task, error) {
+	tasks := []models.Task{}
+	err := DB.Preload("Assignees").Where("id IN (?)", DB.Model(&models.TaskDependency{}).Select("blocker_id").Where("blocked_id = ?", taskID)).
+		Where("status <> ?", models.TaskStatusCompleted).
+		Order("deadline asc, id asc").
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// GetProjectDependencies 获取项目内任务之间的全部依赖关系，不包括回收站中的任务
+func GetProjectDependencies(projectID uint) ([]models.TaskDependency, error) {
+	dependencies := []models.TaskDependency{}
+	err := liveDependencies().Where("blocked_id IN (?)", DB.Model(&models.Task{}).Select("id").Where("project_id = ?", projectID)).
+		Order("blocker_id asc, blocked_id asc").
+		Find(&dependencies).Error
+	return dependencies, err
+}
+
+// CountTaskDependencies 统计任务作为阻塞方或被阻塞方的依赖关系数量，不包括另一端在回收站中的依赖关系
+func CountTaskDependencies(taskID uint) (int64, error) {
+	var count int64
+	err := liveDependencies().Model(&models.TaskDependency{}).Where("blocker_id = ? OR blocked_id = ?", taskID, taskID).Count(&count).Error
+	return count, err
+}
+
+// FindDependencyPath 沿“阻塞”方向查找从from到to的依赖路径，返回路径上的任务ID（含两端），不存在时返回nil。
+// 回收站中的任务恢复后其依赖关系重新生效，因此查找时也经过回收站中的任务
+func FindDependencyPath(from, to uint) ([]uint, error) {
+	if from == to {
+		return []uint{from}, nil
+	}
+
+	// prev 记录广度优先搜索中每个任务的上一个任务，用于还原路径
+	prev := map[uint]uint{from: from}
+	frontier := []uint{from}
+	for len(frontier) > 0 {
+		var edges []models.TaskDependency
+		if err := DB.Where("blocker_id IN ?", frontier).Find(&edges).Error; err != nil {
+			return nil, err
+		}
+
+		frontier = frontier[:0]
+		for _, edge := range edges {
+			if _, seen := prev[edge.BlockedID]; seen {
+				continue
+			}
+			prev[edge.BlockedID] = edge.BlockerID
+			if edge.BlockedID == to {
+				path := []uint{to}
+				for id := to; id != from; {
+					id = prev[id]
+					path = append([]uint{id}, path...)
+				}
+				return path, nil
+			}
+			frontier = append(frontier, edge.BlockedID)
+		}
+	}
+	return nil, nil
+}
+
+// liveDependencies 构造只包含两端任务都不在回收站中的依赖关系的查询
+func liveDependencies() *gorm.DB {
+	live := DB.Model(&models.Task{}).Select("id")
+	return DB.Where("blocker_id IN (?) AND blocked_id IN (?)", live, live)
+}
+
+// deleteTaskDependencies 删除与给定任务相关的全部依赖关系，taskIDs可以是ID列表或子查询
+func deleteTaskDependencies(tx *gorm.DB, taskIDs interface{}) error {
+	return tx.Where("blocker_id IN (?) OR blocked_id IN (?)", taskIDs, taskIDs).Delete(&models.TaskDependency{}).Error
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"project_management/internal/models"
+	"reflect"
+	"testing"
+)
+
+// setupDependencies 创建任务和依赖关系，trashed中的任务移入回收站
+func setupDependencies(t *testing.T, tasks []uint, edges [][2]uint, trashed ...uint) {
+	t.Helper()
+	setupTestDB(t, &models.Task{}, &models.TaskDependency{})
+
+	for _, id := range tasks {
+		if err := DB.Create(&models.Task{ID: id, ProjectID: 1, Name: "任务"}).Error; err != nil {
+			t.Fatalf("create task %d: %v", id, err)
+		}
+	}
+	for _, edge := range edges {
+		if err := CreateTaskDependency(&models.TaskDependency{BlockerID: edge[0], BlockedID: edge[1]}); err != nil {
+			t.Fatalf("create dependency %v: %v", edge, err)
+		}
+	}
+	if len(trashed) > 0 {
+		if err := DB.Delete(&models.Task{}, trashed).Error; err != nil {
+			t.Fatalf("trash tasks %v: %v", trashed, err)
+		}
+	}
+}
+
+func TestFindDependencyPath(t *testing.T) {
+	// 1 -> 2 -> 3 -> 4，1 -> 5 -> 4，4 -> 7 -> 8，其中7在回收站中，6没有依赖关系
+	setupDependencies(t,
+		[]uint{1, 2, 3, 4, 5, 6, 7, 8},
+		[][2]uint{{1, 2}, {2, 3}, {3, 4}, {1, 5}, {5, 4}, {4, 7}, {7, 8}},
+		7,
+	)
+
+	tests := []struct {
+		name     string
+		from, to uint
+		want     []uint
+	}{
+		{"直接依赖", 1, 2, []uint{1, 2}},
+		{"间接依赖", 2, 4, []uint{2, 3, 4}},
+		{"多条路径时返回最短路径", 1, 4, []uint{1, 5, 4}},
+		{"经过回收站中的任务", 3, 8, []uint{3, 4, 7, 8}},
+		{"同一个任务", 6, 6, []uint{6}},
+		{"方向相反", 4, 1, nil},
+		{"没有依赖关系", 1, 6, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindDependencyPath(tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("FindDependencyPath() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindDependencyPath(%d, %d) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindDependencyPathDetectsCycle(t *testing.T) {
+	// 1 -> 2 -> 3，再添加3 -> 1会形成循环
+	setupDependencies(t, []uint{1, 2, 3}, [][2]uint{{1, 2}, {2, 3}})
+
+	tests := []struct {
+		name      string
+		blocker   uint
+		blocked   uint
+		wantCycle []uint
+	}{
+		{"形成循环", 3, 1, []uint{1, 2, 3, 1}},
+		{"任务阻塞自身", 2, 2, []uint{2, 2}},
+		{"不形成循环", 1, 3, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 与添加依赖时的检查一致：被阻塞任务已经直接或间接阻塞了blocker时形成循环
+			path, err := FindDependencyPath(tt.blocked, tt.blocker)
+			if err != nil {
+				t.Fatalf("FindDependencyPath() error = %v", err)
+			}
+			var cycle []uint
+			if path != nil {
+				cycle = append(path, tt.blocked)
+			}
+			if !reflect.DeepEqual(cycle, tt.wantCycle) {
+				t.Errorf("cycle = %v, want %v", cycle, tt.wantCycle)
+			}
+		})
+	}
+}
+
+func TestLiveDependencies(t *testing.T) {
+	setupDependencies(t, []uint{1, 2, 3}, [][2]uint{{1, 2}, {2, 3}}, 3)
+
+	dependency, err := GetTaskDependency(1, 2)
+	if err != nil || dependency == nil {
+		t.Errorf("GetTaskDependency(1, 2) = %v, %v, want dependency", dependency, err)
+	}
+	dependency, err = GetTaskDependency(2, 3)
+	if err != nil || dependency != nil {
+		t.Errorf("GetTaskDependency(2, 3) = %v, %v, want nil for trashed task", dependency, err)
+	}
+
+	count, err := CountTaskDependencies(2)
+	if err != nil || count != 1 {
+		t.Errorf("CountTaskDependencies(2) = %d, %v, want 1", count, err)
+	}
+
+	dependencies, err := GetProjectDependencies(1)
+	if err != nil {
+		t.Fatalf("GetProjectDependencies() error = %v", err)
+	}
+	if len(dependencies) != 1 || dependencies[0].BlockerID != 1 || dependencies[0].BlockedID != 2 {
+		t.Errorf("GetProjectDependencies() = %+v, want only 1 -> 2", dependencies)
+	}
+}
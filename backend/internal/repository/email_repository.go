@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"errors"
+	"project_management/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// EmailExists 检查邮箱是否已被其他用户使用，包括已注销但尚未彻底删除的用户
+func EmailExists(email string, exceptUserID uint) (bool, error) {
+	var count int64
+	err := DB.Unscoped().Model(&models.User{}).Where("email = ? AND id <> ?", email, exceptUserID).Count(&count).Error
+	return count > 0, err
+}
+
+// SetUserEmail 修改用户的邮箱并将其标记为未验证，同时作废之前的验证令牌。
+// email为nil表示删除邮箱，verification不为nil时保存新的验证令牌
+func SetUserEmail(user *models.User, email *string, verification *models.EmailVerification) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.EmailVerification{}).Error; err != nil {
+			return err
+		}
+		err := tx.Model(user).Updates(map[string]interface{}{
+			"email":             email,
+			"email_verified_at": nil,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if verification != nil {
+			return tx.Create(verification).Error
+		}
+		return nil
+	})
+}
+
+// CreateEmailVerification 为用户的当前邮箱重新生成验证令牌，之前的令牌失效
+func CreateEmailVerification(verification *models.EmailVerification) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", verification.UserID).Delete(&models.EmailVerification{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(verification).Error
+	})
+}
+
+// GetLatestEmailVerification 获取用户最近生成的验证令牌
+func GetLatestEmailVerification(userID uint) (*models.EmailVerification, error) {
+	var verification models.EmailVerification
+	err := DB.Where("user_id = ?", userID).Order("created_at desc").First(&verification).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &verification, nil
+}
+
+// GetEmailVerificationByToken 通过令牌获取邮箱验证记录
+func GetEmailVerificationByToken(token string) (*models.EmailVerification, error) {
+	var verification models.EmailVerification
+	err := DB.Where("token = ?", token).First(&verification).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &verification, nil
+}
+
+// VerifyUserEmail 将用户的邮箱标记为已验证并删除验证令牌，unsubscribeToken在用户还没有退订令牌时使用
+func VerifyUserEmail(user *models.User, unsubscribeToken string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.EmailVerification{}).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		updates := map[string]interface{}{"email_verified_at": now}
+		if user.UnsubscribeToken == nil {
+			updates["unsubscribe_token"] = unsubscribeToken
+		}
+		return tx.Model(user).Updates(updates).Error
+	})
+}
+
+// GetUserByUnsubscribeToken 通过退订令牌获取用户
+func GetUserByUnsubscribeToken(token string) (*models.User, error) {
+	var user models.User
+	err := DB.Where("unsubscribe_token = ?", token).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetVerifiedEmailUsers 获取邮箱已验证的用户
+func GetVerifiedEmailUsers() ([]models.User, error) {
+	var users []models.User
+	err := DB.Where("email IS NOT NULL AND email_verified_at IS NOT NULL").Order("id asc").Find(&users).Error
+	return users, err
+}
+
+// CreateEmailDelivery 将邮件加入发送队列
+func CreateEmailDelivery(delivery *models.EmailDelivery) error {
+	return DB.Create(delivery).Error
+}
+
+// EmailDeliveryExists 检查是否已经为用户创建过指定去重键的邮件
+func EmailDeliveryExists(userID uint, dedupKey string) (bool, error) {
+	var count int64
+	err := DB.Model(&models.EmailDelivery{}).Where("user_id = ? AND dedup_key = ?", userID, dedupKey).Count(&count).Error
+	return count > 0, err
+}
+
+// GetDueEmailDeliveries 获取已到发送时间的邮件，最多limit封
+func GetDueEmailDeliveries(now time.Time, limit int) ([]models.EmailDelivery, error) {
+	var deliveries []models.EmailDelivery
+	err := DB.Where("status = ? AND next_attempt_at <= ?", models.EmailPending, now).
+		Order("next_attempt_at asc, id asc").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// SaveEmailDelivery 保存邮件记录
+func SaveEmailDelivery(delivery *models.EmailDelivery) error {
+	return DB.Save(delivery).Error
+}
+
+// DeleteFinishedEmailDeliveries 删除在before之前创建且已结束的邮件记录，返回删除的数量
+func DeleteFinishedEmailDeliveries(before time.Time) (int64, error) {
+	result := DB.Where("status <> ? AND created_at < ?", models.EmailPending, before).
+		Delete(&models.EmailDelivery{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"errors"
+	"project_management/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// AddProjectMember 添加项目成员
+func AddProjectMember(member *models.ProjectMember) error {
+	return DB.Create(member).Error
+}
+
+// GetProjectMember 获取用户在项目中的成员记录
+func GetProjectMember(projectID, userID uint) (*models.ProjectMember, error) {
+	var member models.ProjectMember
+	err := DB.Where("project_id = ? AND user_id = ?", projectID, userID).First(&member).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+// GetProjectMembers 获取项目的所有成员
+func GetProjectMembers(projectID uint) ([]models.ProjectMember, error) {
+	var members []models.ProjectMember
+	err := DB.Preload("User").
+		Where("project_id = ? AND user_id IN (?)", projectID, DB.Model(&models.User{}).Select("id")).
+		Order("id asc").Find(&members).Error
+	return members, err
+}
+
+// UpdateProjectMember 更新项目成员
+func UpdateProjectMember(member *models.ProjectMember) error {
+	return DB.Save(member).Error
+}
+
+// DeleteProjectMember 移除项目成员，同时取消其在该项目中负责的任务
+func DeleteProjectMember(projectID, userID uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		taskIDs := tx.Unscoped().Model(&models.Task{}).Select("id").Where("project_id = ?", projectID)
+		if err := tx.Where("user_id = ? AND task_id IN (?)", userID, taskIDs).Delete(&models.TaskAssignee{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("project_id = ? AND user_id = ?", projectID, userID).Delete(&models.ProjectMember{}).Error
+	})
+}
+
+// CountProjectOwners 统计项目的所有者数量
+func CountProjectOwners(projectID uint) (int64, error) {
+	var count int64
+	err := DB.Model(&models.ProjectMember{}).
+		Where("project_id = ? AND role = ?", projectID, models.ProjectRoleOwner).
+		Count(&count).Error
+	return count, err
+}
+
+// GetSoleOwnedSharedProjectIDs 获取用户是唯一所有者且还有其他成员的项目ID
+func GetSoleOwnedSharedProjectIDs(userID uint) ([]uint, error) {
+	var ids []uint
+	otherOwners := DB.Model(&models.ProjectMember{}).Select("project_id").
+		Where("role = ? AND user_id <> ?", models.ProjectRoleOwner, userID)
+	otherMembers := DB.Model(&models.ProjectMember{}).Select("project_id").Where("user_id <> ?", userID)
+	err := DB.Model(&models.ProjectMember{}).
+		Where("user_id = ? AND role = ?", userID, models.ProjectRoleOwner).
+		Where("project_id NOT IN (?) AND project_id IN (?)", otherOwners, otherMembers).
+		Order("project_id asc").
+		Pluck("project_id", &ids).Error
+	return ids, err
+}
+
+// GetUserProjectIDs 获取用户参与的所有项目ID
+func GetUserProjectIDs(userID uint) ([]uint, error) {
+	var ids []uint
+	err := DB.Model(&models.ProjectMember{}).Where("user_id = ?", userID).Pluck("project_id", &ids).Error
+	return ids, err
+}
+
+// CountProjectMembersIn 统计给定用户中属于项目成员的数量
+func CountProjectMembersIn(projectID uint, userIDs []uint) (int64, error) {
+	var count int64
+	if len(userIDs) == 0 {
+		return 0, nil
+	}
+	err := DB.Model(&models.ProjectMember{}).
+		Where("project_id = ? AND user_id IN ?", projectID, userIDs).
+		Count(&count).Error
+	return count, err
+}
+
+// GetProjectMemberUsersByUsernames 按用户名查找属于项目成员的用户，不存在或不是成员的用户名会被忽略
+func GetProjectMemberUsersByUsernames(projectID uint, usernames []string) ([]models.User, error) {
+	users := []models.User{}
+	if len(usernames) == 0 {
+		return users, nil
+	}
+	err := DB.Joins("JOIN project_members ON project_members.user_id = users.id").
+		Where("project_members.project_id = ? AND users.username IN ?", projectID, usernames).
+		Order("users.id asc").
+		Find(&users).Error
+	return users, err
+}
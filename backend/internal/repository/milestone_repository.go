@@ -2,7 +2,9 @@ package repository
 
 import (
 	"errors"
+	"math"
 	"project_management/internal/models"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -12,13 +14,85 @@ func CreateMilestone(milestone *models.Milestone) error {
 	return DB.Create(milestone).Error
 }
 
-// GetAllMilestones 获取所有里程碑
-func GetAllMilestones() ([]models.Milestone, error) {
-	var milestones []models.Milestone
-	err := DB.Order("date asc").Find(&milestones).Error
+// MilestoneFilter 里程碑列表的筛选条件
+type MilestoneFilter struct {
+	ProjectIDs []uint     // 限定的项目范围，为空时不返回任何里程碑
+	DateFrom   *time.Time // 日期下限（含）
+	DateTo     *time.Time // 日期上限（含）
+	Search     string     // 按标题模糊搜索
+}
+
+// milestoneSortColumns 里程碑列表允许排序的字段
+var milestoneSortColumns = map[string]string{
+	"id":         "id",
+	"title":      "title",
+	"date":       "date",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"project_id": "project_id",
+}
+
+// ListMilestones 按条件分页查询里程碑，返回当前页的里程碑和符合条件的总数
+func ListMilestones(filter MilestoneFilter, opts ListOptions) ([]models.Milestone, int64, error) {
+	milestones := []models.Milestone{}
+	if len(filter.ProjectIDs) == 0 {
+		return milestones, 0, nil
+	}
+
+	// 同一查询条件分别用于统计总数和分页查询
+	query := filterMilestones(filter).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	paged, err := paginate(query, opts, milestoneSortColumns, "date asc, id asc")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = paged.Find(&milestones).Error
+	return milestones, total, err
+}
+
+// ExportMilestones 查询符合条件的全部里程碑，按项目和ID排序
+func ExportMilestones(filter MilestoneFilter) ([]models.Milestone, error) {
+	milestones := []models.Milestone{}
+	if len(filter.ProjectIDs) == 0 {
+		return milestones, nil
+	}
+	err := filterMilestones(filter).Order("project_id, id").Find(&milestones).Error
 	return milestones, err
 }
 
+// CreateMilestones 在同一事务中创建多个里程碑
+func CreateMilestones(milestones []*models.Milestone) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		for _, milestone := range milestones {
+			if err := tx.Create(milestone).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// filterMilestones 根据筛选条件构造里程碑查询
+func filterMilestones(filter MilestoneFilter) *gorm.DB {
+	query := DB.Model(&models.Milestone{}).Where("project_id IN ?", filter.ProjectIDs)
+	if filter.DateFrom != nil {
+		query = query.Where("date >= ?", *filter.DateFrom)
+	}
+	if filter.DateTo != nil {
+		query = query.Where("date <= ?", *filter.DateTo)
+	}
+	if filter.Search != "" {
+		query = query.Where("title LIKE ?"+likeEscapeClause, containsPattern(filter.Search))
+	}
+	return query
+}
+
 // GetMilestoneByID 通过ID获取里程碑
 func GetMilestoneByID(id uint) (*models.Milestone, error) {
 	var milestone models.Milestone
@@ -32,12 +106,69 @@ func GetMilestoneByID(id uint) (*models.Milestone, error) {
 	return &milestone, nil
 }
 
-// UpdateMilestone 更新里程碑
+// UpdateMilestone 更新里程碑并将版本号加一，数据库中的版本号与milestone.Version不一致时返回ErrVersionConflict
 func UpdateMilestone(milestone *models.Milestone) error {
-	return DB.Save(milestone).Error
+	return saveWithVersion(DB, milestone, &milestone.Version)
+}
+
+// CountMilestoneTasks 统计关联到里程碑的任务数量，包括回收站中的任务
+func CountMilestoneTasks(id uint) (int64, error) {
+	var count int64
+	err := DB.Unscoped().Model(&models.Task{}).Where("milestone_id = ?", id).Count(&count).Error
+	return count, err
 }
 
-// DeleteMilestone 删除里程碑
+// DeleteMilestone 将里程碑移入回收站，并解除任务（包括回收站中的任务）与该里程碑的关联，评论保留到彻底删除时。
+// 解除关联的里程碑记录在任务的trashed_milestone_id中，恢复里程碑时重新关联
 func DeleteMilestone(id uint) error {
-	return DB.Delete(&models.Milestone{}, id).Error
-} 
\ No newline at end of file
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Task{}).Where("milestone_id = ?", id).Updates(map[string]interface{}{
+			"milestone_id":         nil,
+			"trashed_milestone_id": id,
+			"version":              gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Milestone{}, id).Error
+	})
+}
+
+// GetMilestoneProgress 批量统计里程碑的任务进度，返回以里程碑ID为键的进度
+func GetMilestoneProgress(milestoneIDs []uint) (map[uint]*models.MilestoneProgress, error) {
+	progress := make(map[uint]*models.MilestoneProgress, len(milestoneIDs))
+	for _, id := range milestoneIDs {
+		progress[id] = &models.MilestoneProgress{}
+	}
+	if len(milestoneIDs) == 0 {
+		return progress, nil
+	}
+
+	var rows []struct {
+		MilestoneID uint
+		Total       int64
+		Completed   int64
+		Overdue     int64
+	}
+	err := DB.Model(&models.Task{}).
+		Select("milestone_id, COUNT(*) AS total, "+
+			"SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS completed, "+
+			"SUM(CASE WHEN status <> ? AND deadline < ? THEN 1 ELSE 0 END) AS overdue",
+			models.TaskStatusCompleted, models.TaskStatusCompleted, models.OverdueCutoff()).
+		Where("milestone_id IN ?", milestoneIDs).
+		Group("milestone_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		p := progress[row.MilestoneID]
+		p.Total = row.Total
+		p.Completed = row.Completed
+		p.Overdue = row.Overdue
+		if row.Total > 0 {
+			p.Percent = math.Round(float64(row.Completed)*10000/float64(row.Total)) / 100
+		}
+	}
+	return progress, nil
+}
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"errors"
+	"project_management/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CreateNotifications 批量创建通知
+func CreateNotifications(notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return DB.Create(&notifications).Error
+}
+
+// NotificationFilter 通知列表的筛选条件
+type NotificationFilter struct {
+	UserID     uint                      // 接收人
+	UnreadOnly bool                      // 只返回未读通知
+	Types      []models.NotificationType // 通知类型
+}
+
+// notificationSortColumns 通知列表允许排序的字段
+var notificationSortColumns = map[string]string{
+	"id":         "id",
+	"created_at": "created_at",
+}
+
+// ListNotifications 按条件分页查询通知，返回当前页的通知和符合条件的总数
+func ListNotifications(filter NotificationFilter, opts ListOptions) ([]models.Notification, int64, error) {
+	notifications := []models.Notification{}
+
+	query := DB.Model(&models.Notification{}).Where("user_id = ?", filter.UserID)
+	if filter.UnreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	if len(filter.Types) > 0 {
+		query = query.Where("type IN ?", filter.Types)
+	}
+
+	// 同一查询条件分别用于统计总数和分页查询
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	paged, err := paginate(query, opts, notificationSortColumns, "created_at desc, id desc")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = paged.Preload("Actor").Find(&notifications).Error
+	return notifications, total, err
+}
+
+// CountUnreadNotifications 按类型统计用户的未读通知数
+func CountUnreadNotifications(userID uint) (map[models.NotificationType]int64, error) {
+	var rows []struct {
+		Type  models.NotificationType
+		Count int64
+	}
+	err := DB.Model(&models.Notification{}).
+		Select("type, COUNT(*) AS count").
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Group("type").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[models.NotificationType]int64, len(models.NotificationTypes))
+	for _, t := range models.NotificationTypes {
+		counts[t] = 0
+	}
+	for _, row := range rows {
+		counts[row.Type] = row.Count
+	}
+	return counts, nil
+}
+
+// GetUserNotification 获取用户的通知
+func GetUserNotification(userID, id uint) (*models.Notification, error) {
+	var notification models.Notification
+	err := DB.Preload("Actor").Where("user_id = ?", userID).First(&notification, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// MarkNotificationRead 将通知标记为已读，已读的通知保持原来的已读时间
+func MarkNotificationRead(notification *models.Notification) error {
+	if notification.ReadAt != nil {
+		return nil
+	}
+	now := time.Now()
+	err := DB.Model(&models.Notification{}).Where("id = ? AND read_at IS NULL", notification.ID).
+		Update("read_at", now).Error
+	if err == nil {
+		notification.ReadAt = &now
+	}
+	return err
+}
+
+// MarkAllNotificationsRead 将用户的未读通知全部标记为已读，types不为空时只处理这些类型，返回标记的数量
+func MarkAllNotificationsRead(userID uint, types []models.NotificationType) (int64, error) {
+	query := DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID)
+	if len(types) > 0 {
+		query = query.Where("type IN ?", types)
+	}
+	result := query.Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// NotificationSent 检查是否已经向用户发送过指定去重键的通知
+func NotificationSent(userID uint, dedupKey string) (bool, error) {
+	var count int64
+	err := DB.Model(&models.Notification{}).Where("user_id = ? AND dedup_key = ?", userID, dedupKey).Count(&count).Error
+	return count > 0, err
+}
+
+// GetNotificationPreference 获取用户的通知偏好，没有记录时返回默认偏好
+func GetNotificationPreference(userID uint) (models.NotificationPreference, error) {
+	preferences, err := GetNotificationPreferences([]uint{userID})
+	return preferences[userID], err
+}
+
+// GetNotificationPreferences 批量获取用户的通知偏好，没有记录的用户使用默认偏好
+func GetNotificationPreferences(userIDs []uint) (map[uint]models.NotificationPreference, error) {
+	preferences := make(map[uint]models.NotificationPreference, len(userIDs))
+	for _, id := range userIDs {
+		preferences[id] = models.DefaultNotificationPreference(id)
+	}
+	if len(userIDs) == 0 {
+		return preferences, nil
+	}
+
+	var saved []models.NotificationPreference
+	if err := DB.Where("user_id IN ?", userIDs).Find(&saved).Error; err != nil {
+		return preferences, err
+	}
+	for _, preference := range saved {
+		preferences[preference.UserID] = preference
+	}
+	return preferences, nil
+}
+
+// SaveNotificationPreference 保存用户的通知偏好
+func SaveNotificationPreference(preference *models.NotificationPreference) error {
+	return DB.Save(preference).Error
+}
+
+// GetUpcomingTasks 获取截止日期在[from, to]之间且未完成的任务，包含负责人
+func GetUpcomingTasks(from, to time.Time) ([]models.Task, error) {
+	var tasks []models.Task
+	err := DB.Preload("Assignees").
+		Where("deadline >= ? AND deadline <= ? AND status <> ?", from, to, models.TaskStatusCompleted).
+		Order("deadline asc, id asc").
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// deleteEntityNotifications 删除与任务或里程碑相关的通知
+func deleteEntityNotifications(tx *gorm.DB, entityType models.EntityType, entityIDs interface{}) error {
+	return tx.Where("entity_type = ? AND entity_id IN (?)", entityType, entityIDs).Delete(&models.Notification{}).Error
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// 分页默认值
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+var (
+	ErrInvalidSortField = errors.New("不支持的排序字段")
+	ErrInvalidSortOrder = errors.New("无效的排序方向")
+)
+
+// ListOptions 列表查询的排序和分页参数
+type ListOptions struct {
+	Sort     string // 排序字段，为空时使用默认排序
+	Order    string // asc 或 desc
+	Page     int    // 从1开始的页码
+	PageSize int    // 每页数量
+}
+
+// Normalize 补全分页参数的默认值并限制每页数量
+func (o *ListOptions) Normalize() {
+	if o.Page < 1 {
+		o.Page = 1
+	}
+	if o.PageSize < 1 {
+		o.PageSize = DefaultPageSize
+	}
+	if o.PageSize > MaxPageSize {
+		o.PageSize = MaxPageSize
+	}
+}
+
+// paginate 在查询上应用排序和分页。
+// sortColumns 为允许排序的字段到SQL表达式的映射，defaultOrder 为未指定排序时使用的排序语句。
+func paginate(db *gorm.DB, opts ListOptions, sortColumns map[string]string, defaultOrder string) (*gorm.DB, error) {
+	opts.Normalize()
+
+	if opts.Sort == "" {
+		db = db.Order(defaultOrder)
+	} else {
+		column, ok := sortColumns[opts.Sort]
+		if !ok {
+			return nil, ErrInvalidSortField
+		}
+
+		order := strings.ToLower(opts.Order)
+		switch order {
+		case "":
+			order = "asc"
+		case "asc", "desc":
+		default:
+			return nil, ErrInvalidSortOrder
+		}
+		// 附加ID排序保证分页结果稳定
+		db = db.Order(column + " " + order).Order("id " + order)
+	}
+
+	return db.Offset((opts.Page - 1) * opts.PageSize).Limit(opts.PageSize), nil
+}
+
+// likeEscapeClause LIKE查询使用的转义子句，选用 ! 作为转义符以兼容MySQL和SQLite
+const likeEscapeClause = " ESCAPE '!'"
+
+// containsPattern 返回匹配包含s的LIKE模式，并转义其中的通配符
+func containsPattern(s string) string {
+	return "%" + strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`).Replace(s) + "%"
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"errors"
+	"project_management/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CreateProject 创建项目，并将创建者添加为项目所有者
+func CreateProject(project *models.Project) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(project).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.ProjectMember{
+			ProjectID: project.ID,
+			UserID:    project.OwnerID,
+			Role:      models.ProjectRoleOwner,
+		}).Error
+	})
+}
+
+// GetProjectsByUser 获取用户参与的所有项目
+func GetProjectsByUser(userID uint) ([]models.Project, error) {
+	var projects []models.Project
+	err := DB.Where("id IN (?)", DB.Model(&models.ProjectMember{}).Select("project_id").Where("user_id = ?", userID)).
+		Order("created_at desc").Find(&projects).Error
+	return projects, err
+}
+
+// GetProjectsByIDs 获取指定ID的项目，按ID排序
+func GetProjectsByIDs(ids []uint) ([]models.Project, error) {
+	projects := []models.Project{}
+	if len(ids) == 0 {
+		return projects, nil
+	}
+	err := DB.Where("id IN ?", ids).Order("id asc").Find(&projects).Error
+	return projects, err
+}
+
+// GetProjectByID 通过ID获取项目
+func GetProjectByID(id uint) (*models.Project, error) {
+	var project models.Project
+	err := DB.First(&project, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &project, nil
+}
+
+// UpdateProject 更新项目
+func UpdateProject(project *models.Project) error {
+	return DB.Save(project).Error
+}
+
+// DeleteProject 删除项目及其下的所有任务、里程碑（包括回收站中的）、评论、附件记录、成员和操作记录，
+// 附件文件由调用方从存储后端删除
+func DeleteProject(id uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		taskIDs := tx.Unscoped().Model(&models.Task{}).Select("id").Where("project_id = ?", id)
+		if err := tx.Where("task_id IN (?)", taskIDs).Delete(&models.TaskAssignee{}).Error; err != nil {
+			return err
+		}
+		if err := deleteTaskDependencies(tx, taskIDs); err != nil {
+			return err
+		}
+		if err := deleteEntityComments(tx, models.EntityTask, taskIDs); err != nil {
+			return err
+		}
+		if err := tx.Where("task_id IN (?)", taskIDs).Delete(&models.Attachment{}).Error; err != nil {
+			return err
+		}
+		milestoneIDs := tx.Unscoped().Model(&models.Milestone{}).Select("id").Where("project_id = ?", id)
+		if err := deleteEntityComments(tx, models.EntityMilestone, milestoneIDs); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("project_id = ?", id).Delete(&models.Task{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("project_id = ?", id).Delete(&models.Milestone{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("project_id = ?", id).Delete(&models.ProjectMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("project_id = ?", id).Delete(&models.Activity{}).Error; err != nil {
+			return err
+		}
+		if err := deleteProjectWebhooks(tx, id); err != nil {
+			return err
+		}
+		if err := tx.Where("project_id = ?", id).Delete(&models.Notification{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Project{}, id).Error
+	})
+}
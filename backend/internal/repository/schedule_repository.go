@@ -0,0 +1,19 @@
+package repository
+
+import "project_management/internal/models"
+
+// GetOpenProjectTasks 获取项目中未完成的任务，用于排期计算
+func GetOpenProjectTasks(projectID uint) ([]models.Task, error) {
+	tasks := []models.Task{}
+	err := DB.Where("project_id = ? AND status <> ?", projectID, models.TaskStatusCompleted).
+		Order("deadline asc, id asc").
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// GetProjectMilestoneList 获取项目的全部里程碑，按日期排序
+func GetProjectMilestoneList(projectID uint) ([]models.Milestone, error) {
+	milestones := []models.Milestone{}
+	err := DB.Where("project_id = ?", projectID).Order("date asc, id asc").Find(&milestones).Error
+	return milestones, err
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"math"
+	"project_management/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GetTaskStats 统计指定项目范围内的任务数据，upcomingUntil 之前的未到期里程碑作为近期里程碑返回
+func GetTaskStats(projectIDs []uint, upcomingUntil time.Time) (*models.TaskStats, error) {
+	stats := &models.TaskStats{
+		ByStatus:           make(map[models.TaskStatus]int64, len(models.TaskStatuses)),
+		ByUrgency:          make(map[models.TaskUrgency]int64, len(models.TaskUrgencies)),
+		UpcomingMilestones: []models.Milestone{},
+		Workload:           []models.AssigneeWorkload{},
+	}
+	for _, status := range models.TaskStatuses {
+		stats.ByStatus[status] = 0
+	}
+	for _, urgency := range models.TaskUrgencies {
+		stats.ByUrgency[urgency] = 0
+	}
+	if len(projectIDs) == 0 {
+		return stats, nil
+	}
+
+	tasks := DB.Model(&models.Task{}).Where("project_id IN ?", projectIDs)
+
+	// 按状态分组统计
+	var statusRows []struct {
+		Status models.TaskStatus
+		Count  int64
+	}
+	err := tasks.Session(&gorm.Session{}).Select("status, COUNT(*) AS count").Group("status").Scan(&statusRows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range statusRows {
+		stats.ByStatus[row.Status] = row.Count
+		stats.Total += row.Count
+	}
+
+	// 按紧急程度分组统计
+	var urgencyRows []struct {
+		Urgency models.TaskUrgency
+		Count   int64
+	}
+	err = tasks.Session(&gorm.Session{}).Select("urgency, COUNT(*) AS count").Group("urgency").Scan(&urgencyRows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range urgencyRows {
+		stats.ByUrgency[row.Urgency] = row.Count
+	}
+
+	// 逾期任务
+	err = tasks.Session(&gorm.Session{}).
+		Where("status <> ? AND deadline < ?", models.TaskStatusCompleted, models.OverdueCutoff()).
+		Count(&stats.Overdue).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// 未分配负责人的任务
+	err = tasks.Session(&gorm.Session{}).
+		Where("id NOT IN (?)", DB.Model(&models.TaskAssignee{}).Select("task_id")).
+		Count(&stats.Unassigned).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if stats.Total > 0 {
+		completed := stats.ByStatus[models.TaskStatusCompleted]
+		stats.CompletionRate = math.Round(float64(completed)*10000/float64(stats.Total)) / 100
+	}
+
+	// 负责人负载
+	err = DB.Table("task_assignees").
+		Select("users.id AS user_id, users.username, users.name, COUNT(*) AS total, "+
+			"SUM(CASE WHEN tasks.status <> ? THEN 1 ELSE 0 END) AS open, "+
+			"SUM(CASE WHEN tasks.status <> ? AND tasks.deadline < ? THEN 1 ELSE 0 END) AS overdue",
+			models.TaskStatusCompleted, models.TaskStatusCompleted, models.OverdueCutoff()).
+		Joins("JOIN tasks ON tasks.id = task_assignees.task_id AND tasks.deleted_at IS NULL").
+		Joins("JOIN users ON users.id = task_assignees.user_id AND users.deleted_at IS NULL").
+		Where("tasks.project_id IN ?", projectIDs).
+		Group("users.id, users.username, users.name").
+		Order("open desc, total desc, users.id asc").
+		Scan(&stats.Workload).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// 近期里程碑
+	err = DB.Where("project_id IN ? AND date >= ? AND date <= ?", projectIDs, models.OverdueCutoff(), upcomingUntil).
+		Order("date asc, id asc").
+		Find(&stats.UpcomingMilestones).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
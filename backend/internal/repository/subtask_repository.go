@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"math"
+	"project_management/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GetTasksByIDs 批量获取任务
+func GetTasksByIDs(ids []uint) ([]models.Task, error) {
+	tasks := []models.Task{}
+	if len(ids) == 0 {
+		return tasks, nil
+	}
+	err := DB.Preload("Assignees").Where("id IN ?", ids).Order("id asc").Find(&tasks).Error
+	return tasks, err
+}
+
+// GetTaskChildren 获取任务的直接子任务
+func GetTaskChildren(id uint) ([]models.Task, error) {
+	tasks := []models.Task{}
+	err := DB.Preload("Assignees").Where("parent_id = ?", id).Order("id asc").Find(&tasks).Error
+	return tasks, err
+}
+
+// CountTaskChildren 统计任务的直接子任务数量
+func CountTaskChildren(id uint) (int64, error) {
+	var count int64
+	err := DB.Model(&models.Task{}).Where("parent_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+// GetTaskDescendantIDs 获取任务的全部下级任务ID，不包含任务本身
+func GetTaskDescendantIDs(id uint) ([]uint, error) {
+	return taskDescendantIDs(DB, id)
+}
+
+// taskDescendantIDs 逐层向下查询子任务，层数等于树的深度
+func taskDescendantIDs(tx *gorm.DB, id uint) ([]uint, error) {
+	var descendants []uint
+	visited := map[uint]bool{id: true}
+	frontier := []uint{id}
+	for len(frontier) > 0 {
+		var children []uint
+		if err := tx.Model(&models.Task{}).Where("parent_id IN ?", frontier).Pluck("id", &children).Error; err != nil {
+			return nil, err
+		}
+
+		frontier = frontier[:0]
+		for _, child := range children {
+			if !visited[child] {
+				visited[child] = true
+				descendants = append(descendants, child)
+				frontier = append(frontier, child)
+			}
+		}
+	}
+	return descendants, nil
+}
+
+// GetTaskRollups 批量计算任务的子任务汇总，返回以任务ID为键的汇总，没有子任务的任务不在结果中
+func GetTaskRollups(ids []uint) (map[uint]*models.TaskRollup, error) {
+	rollups := make(map[uint]*models.TaskRollup)
+
+	// rootOf 记录当前层每个任务所属的汇总任务
+	rootOf := make(map[uint]uint, len(ids))
+	frontier := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		rootOf[id] = id
+		frontier = append(frontier, id)
+	}
+
+	for len(frontier) > 0 {
+		var children []struct {
+			ID       uint
+			ParentID uint
+			Status   models.TaskStatus
+			Deadline time.Time
+		}
+		err := DB.Model(&models.Task{}).
+			Select("id, parent_id, status, deadline").
+			Where("parent_id IN ?", frontier).
+			Scan(&children).Error
+		if err != nil {
+			return nil, err
+		}
+
+		frontier = frontier[:0]
+		for _, child := range children {
+			if _, seen := rootOf[child.ID]; seen {
+				continue
+			}
+			root := rootOf[child.ParentID]
+			rootOf[child.ID] = root
+			frontier = append(frontier, child.ID)
+
+			rollup := rollups[root]
+			if rollup == nil {
+				rollup = &models.TaskRollup{}
+				rollups[root] = rollup
+			}
+			rollup.Total++
+			if child.Status == models.TaskStatusCompleted {
+				rollup.Completed++
+			}
+			if rollup.EarliestDeadline == nil || child.Deadline.Before(*rollup.EarliestDeadline) {
+				deadline := child.Deadline
+				rollup.EarliestDeadline = &deadline
+			}
+		}
+	}
+
+	for _, rollup := range rollups {
+		rollup.Percent = math.Round(float64(rollup.Completed)*10000/float64(rollup.Total)) / 100
+	}
+	return rollups, nil
+}
@@ -2,27 +2,140 @@ package repository
 
 import (
 	"errors"
+	"fmt"
 	"project_management/internal/models"
+	"time"
 
 	"gorm.io/gorm"
 )
 
-// CreateTask 创建任务
+// CreateTask 创建任务，同时写入任务负责人
 func CreateTask(task *models.Task) error {
-	return DB.Create(task).Error
+	return DB.Omit("Assignees.*").Create(task).Error
 }
 
-// GetAllTasks 获取所有任务
-func GetAllTasks() ([]models.Task, error) {
-	var tasks []models.Task
-	err := DB.Order("created_at desc").Find(&tasks).Error
+// TaskFilter 任务列表的筛选条件
+type TaskFilter struct {
+	ProjectIDs   []uint               // 限定的项目范围，为空时不返回任何任务
+	MilestoneID  *uint                // 所属里程碑
+	NoMilestone  bool                 // 仅返回未关联里程碑的任务
+	ParentID     *uint                // 父任务，即只返回该任务的直接子任务
+	NoParent     bool                 // 仅返回顶层任务
+	AssigneeID   *uint                // 负责人
+	Statuses     []models.TaskStatus  // 任务状态，多个状态为“或”关系
+	Urgencies    []models.TaskUrgency // 紧急程度，多个值为“或”关系
+	DeadlineFrom *time.Time           // 截止日期下限（含）
+	DeadlineTo   *time.Time           // 截止日期上限（含）
+	Search       string               // 按任务名称模糊搜索
+}
+
+// taskSortColumns 任务列表允许排序的字段
+var taskSortColumns = map[string]string{
+	"id":           "id",
+	"name":         "name",
+	"deadline":     "deadline",
+	"created_at":   "created_at",
+	"updated_at":   "updated_at",
+	"project_id":   "project_id",
+	"milestone_id": "milestone_id",
+	"parent_id":    "parent_id",
+	// 状态和紧急程度按业务顺序而不是字符串顺序排序
+	"status": fmt.Sprintf("CASE status WHEN '%s' THEN 1 WHEN '%s' THEN 2 WHEN '%s' THEN 3 WHEN '%s' THEN 4 ELSE 5 END",
+		models.TaskStatusPending, models.TaskStatusInProcess, models.TaskStatusDelayed, models.TaskStatusCompleted),
+	"urgency": fmt.Sprintf("CASE urgency WHEN '%s' THEN 1 WHEN '%s' THEN 2 WHEN '%s' THEN 3 WHEN '%s' THEN 4 ELSE 0 END",
+		models.TaskUrgencyLow, models.TaskUrgencyMedium, models.TaskUrgencyHigh, models.TaskUrgencyUrgent),
+}
+
+// ListTasks 按条件分页查询任务，返回当前页的任务和符合条件的总数
+func ListTasks(filter TaskFilter, opts ListOptions) ([]models.Task, int64, error) {
+	tasks := []models.Task{}
+	if len(filter.ProjectIDs) == 0 {
+		return tasks, 0, nil
+	}
+
+	// 同一查询条件分别用于统计总数和分页查询
+	query := filterTasks(filter).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	paged, err := paginate(query, opts, taskSortColumns, "created_at desc, id desc")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = paged.Preload("Assignees").Find(&tasks).Error
+	return tasks, total, err
+}
+
+// ExportTasks 查询符合条件的全部任务，按项目和ID排序
+func ExportTasks(filter TaskFilter) ([]models.Task, error) {
+	tasks := []models.Task{}
+	if len(filter.ProjectIDs) == 0 {
+		return tasks, nil
+	}
+	err := filterTasks(filter).Preload("Assignees").Order("project_id, id").Find(&tasks).Error
 	return tasks, err
 }
 
+// CreateTasks 在同一事务中创建多个任务，同时写入任务负责人。
+// parents[i]为tasks[i]的父任务在tasks中的下标，必须排在tasks[i]之前，-1表示没有父任务
+func CreateTasks(tasks []*models.Task, parents []int) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		for i, task := range tasks {
+			if parents[i] >= 0 {
+				task.ParentID = &tasks[parents[i]].ID
+			}
+			if err := tx.Omit("Assignees.*").Create(task).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// filterTasks 根据筛选条件构造任务查询
+func filterTasks(filter TaskFilter) *gorm.DB {
+	query := DB.Model(&models.Task{}).Where("project_id IN ?", filter.ProjectIDs)
+	if filter.MilestoneID != nil {
+		query = query.Where("milestone_id = ?", *filter.MilestoneID)
+	}
+	if filter.NoMilestone {
+		query = query.Where("milestone_id IS NULL")
+	}
+	if filter.ParentID != nil {
+		query = query.Where("parent_id = ?", *filter.ParentID)
+	}
+	if filter.NoParent {
+		query = query.Where("parent_id IS NULL")
+	}
+	if filter.AssigneeID != nil {
+		query = query.Where("id IN (?)", DB.Model(&models.TaskAssignee{}).Select("task_id").Where("user_id = ?", *filter.AssigneeID))
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if len(filter.Urgencies) > 0 {
+		query = query.Where("urgency IN ?", filter.Urgencies)
+	}
+	if filter.DeadlineFrom != nil {
+		query = query.Where("deadline >= ?", *filter.DeadlineFrom)
+	}
+	if filter.DeadlineTo != nil {
+		query = query.Where("deadline <= ?", *filter.DeadlineTo)
+	}
+	if filter.Search != "" {
+		query = query.Where("name LIKE ?"+likeEscapeClause, containsPattern(filter.Search))
+	}
+	return query
+}
+
 // GetTaskByID 通过ID获取任务
 func GetTaskByID(id uint) (*models.Task, error) {
 	var task models.Task
-	err := DB.First(&task, id).Error
+	err := DB.Preload("Assignees").First(&task, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
@@ -32,12 +145,108 @@ func GetTaskByID(id uint) (*models.Task, error) {
 	return &task, nil
 }
 
-// UpdateTask 更新任务
+// UpdateTask 更新任务并将版本号加一，任务负责人以task.Assignees为准整体替换。
+// 数据库中的版本号与task.Version不一致时返回ErrVersionConflict
 func UpdateTask(task *models.Task) error {
-	return DB.Save(task).Error
+	return DB.Transaction(func(tx *gorm.DB) error {
+		return updateTask(tx, task)
+	})
+}
+
+// updateTask 在事务tx中保存任务并替换负责人
+func updateTask(tx *gorm.DB, task *models.Task) error {
+	if err := saveWithVersion(tx, task, &task.Version, "Assignees"); err != nil {
+		return err
+	}
+	return replaceTaskAssignees(tx, task.ID, task.Assignees)
+}
+
+// MarkOverdueTasksDelayed 将截止日期早于cutoff且处于fromStatuses中的任务标记为已延期，
+// 返回被标记任务在标记前的ID、项目和状态。查询后被其他请求修改了状态或截止日期的任务不会被标记
+func MarkOverdueTasksDelayed(cutoff time.Time, fromStatuses []models.TaskStatus) ([]models.Task, error) {
+	var marked []models.Task
+	if len(fromStatuses) == 0 {
+		return marked, nil
+	}
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var tasks []models.Task
+		err := tx.Select("id", "project_id", "status").
+			Where("status IN ? AND deadline < ?", fromStatuses, cutoff).
+			Find(&tasks).Error
+		if err != nil {
+			return err
+		}
+
+		// 逐个按查询时的状态重新检查后更新，只返回实际更新的任务，保证记录的原状态准确
+		now := time.Now()
+		for _, task := range tasks {
+			result := tx.Model(&models.Task{}).
+				Where("id = ? AND status = ? AND deadline < ?", task.ID, task.Status, cutoff).
+				Updates(map[string]interface{}{
+					"status":          models.TaskStatusDelayed,
+					"auto_delayed_at": now,
+					"updated_at":      now,
+					"version":         gorm.Expr("version + 1"),
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				marked = append(marked, task)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return marked, nil
 }
 
-// DeleteTask 删除任务
-func DeleteTask(id uint) error {
-	return DB.Delete(&models.Task{}, id).Error
-} 
\ No newline at end of file
+// DeleteTask 将任务移入回收站，依赖关系、负责人、评论和附件保留到从回收站彻底删除时。
+// cascade为true时一并删除全部下级任务，否则直接子任务上移到被删除任务的父任务下，
+// 原父任务记录在子任务的trashed_parent_id中，恢复任务时移回
+func DeleteTask(id uint, cascade bool) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		return deleteTask(tx, id, cascade)
+	})
+}
+
+// deleteTask 在事务tx中将任务移入回收站
+func deleteTask(tx *gorm.DB, id uint, cascade bool) error {
+	ids := []uint{id}
+	if cascade {
+		descendants, err := taskDescendantIDs(tx, id)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, descendants...)
+	} else {
+		var task models.Task
+		if err := tx.Select("id", "parent_id").First(&task, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Task{}).Where("parent_id = ?", id).Updates(map[string]interface{}{
+			"parent_id":         task.ParentID,
+			"trashed_parent_id": id,
+			"version":           gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Where("id IN ?", ids).Delete(&models.Task{}).Error
+}
+
+// replaceTaskAssignees 替换任务的负责人
+func replaceTaskAssignees(tx *gorm.DB, taskID uint, users []models.User) error {
+	if err := tx.Where("task_id = ?", taskID).Delete(&models.TaskAssignee{}).Error; err != nil {
+		return err
+	}
+	for _, user := range users {
+		if err := tx.Create(&models.TaskAssignee{TaskID: taskID, UserID: user.ID}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
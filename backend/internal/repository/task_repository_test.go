@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"project_management/internal/models"
+	"reflect"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestMarkOverdueTasksDelayed(t *testing.T) {
+	setupTestDB(t, &models.Task{})
+	cutoff := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	overdue, due := cutoff.AddDate(0, 0, -1), cutoff
+
+	tasks := []models.Task{
+		{ID: 1, Status: models.TaskStatusPending, Deadline: overdue},
+		{ID: 2, Status: models.TaskStatusInProcess, Deadline: overdue},
+		{ID: 3, Status: models.TaskStatusCompleted, Deadline: overdue},
+		{ID: 4, Status: models.TaskStatusDelayed, Deadline: overdue},
+		{ID: 5, Status: models.TaskStatusPending, Deadline: due},
+		{ID: 6, Status: models.TaskStatusPending, Deadline: overdue},
+	}
+	for i := range tasks {
+		tasks[i].ProjectID = 1
+		tasks[i].Name = "任务"
+		if err := DB.Create(&tasks[i]).Error; err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+	}
+	// 回收站中的任务不处理
+	DB.Delete(&models.Task{}, 6)
+
+	marked, err := MarkOverdueTasksDelayed(cutoff, []models.TaskStatus{models.TaskStatusPending, models.TaskStatusInProcess})
+	if err != nil {
+		t.Fatalf("MarkOverdueTasksDelayed() error = %v", err)
+	}
+	got := map[uint]models.TaskStatus{}
+	for _, task := range marked {
+		got[task.ID] = task.Status
+	}
+	want := map[uint]models.TaskStatus{1: models.TaskStatusPending, 2: models.TaskStatusInProcess}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("marked = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		id          uint
+		status      models.TaskStatus
+		version     uint
+		autoDelayed bool
+	}{
+		{1, models.TaskStatusDelayed, 2, true},
+		{2, models.TaskStatusDelayed, 2, true},
+		{3, models.TaskStatusCompleted, 1, false},
+		{4, models.TaskStatusDelayed, 1, false},
+		{5, models.TaskStatusPending, 1, false},
+	}
+	for _, tt := range tests {
+		var task models.Task
+		DB.First(&task, tt.id)
+		if task.Status != tt.status || task.Version != tt.version || (task.AutoDelayedAt != nil) != tt.autoDelayed {
+			t.Errorf("task %d status = %s version = %d auto delayed at = %v, want %s %d %v",
+				tt.id, task.Status, task.Version, task.AutoDelayedAt, tt.status, tt.version, tt.autoDelayed)
+		}
+	}
+}
+
+func TestMarkOverdueTasksDelayedSkipsConcurrentChanges(t *testing.T) {
+	setupTestDB(t, &models.Task{})
+	cutoff := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	for id := uint(1); id <= 3; id++ {
+		task := models.Task{ID: id, ProjectID: 1, Name: "任务", Status: models.TaskStatusPending, Deadline: cutoff.AddDate(0, 0, -1)}
+		if err := DB.Create(&task).Error; err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+	}
+
+	// 在查询逾期任务之后、更新之前，任务2被标记为已完成，任务3被推迟截止日期
+	fired := false
+	err := DB.Callback().Query().After("gorm:query").Register("test:concurrent_update", func(db *gorm.DB) {
+		if fired || db.Statement.Table != "tasks" {
+			return
+		}
+		fired = true
+		tx := db.Session(&gorm.Session{NewDB: true})
+		tx.Table("tasks").Where("id = ?", 2).UpdateColumn("status", models.TaskStatusCompleted)
+		tx.Table("tasks").Where("id = ?", 3).UpdateColumn("deadline", cutoff)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	marked, err := MarkOverdueTasksDelayed(cutoff, []models.TaskStatus{models.TaskStatusPending})
+	if err != nil {
+		t.Fatalf("MarkOverdueTasksDelayed() error = %v", err)
+	}
+	if !fired {
+		t.Fatal("concurrent update was not simulated")
+	}
+	if len(marked) != 1 || marked[0].ID != 1 {
+		t.Errorf("marked = %+v, want only task 1", marked)
+	}
+
+	want := map[uint]models.TaskStatus{1: models.TaskStatusDelayed, 2: models.TaskStatusCompleted, 3: models.TaskStatusPending}
+	for id, status := range want {
+		var task models.Task
+		DB.First(&task, id)
+		if task.Status != status {
+			t.Errorf("task %d status = %s, want %s", id, task.Status, status)
+		}
+	}
+}
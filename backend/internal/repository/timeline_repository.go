@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"project_management/internal/models"
+	"time"
+)
+
+// GetTimelineTasks 获取项目中与[from, to]有交集的任务。未设置开始日期的任务按截止日期推算开始日期，
+// 因此只能在数据库中按截止日期不晚于latestDeadline粗略筛选，调用方需要再按推算的开始日期过滤
+func GetTimelineTasks(projectIDs []uint, from, to, latestDeadline time.Time) ([]models.Task, error) {
+	tasks := []models.Task{}
+	if len(projectIDs) == 0 {
+		return tasks, nil
+	}
+	err := DB.Preload("Assignees").
+		Where("project_id IN ? AND deadline >= ?", projectIDs, from).
+		Where("start_date <= ? OR (start_date IS NULL AND deadline <= ?)", to, latestDeadline).
+		Order("deadline asc, id asc").
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// GetTimelineMilestones 获取项目中日期在[from, to]内的里程碑，以及ids指定的里程碑
+func GetTimelineMilestones(projectIDs []uint, from, to time.Time, ids []uint) ([]models.Milestone, error) {
+	milestones := []models.Milestone{}
+	if len(projectIDs) == 0 {
+		return milestones, nil
+	}
+	query := DB.Where("project_id IN ?", projectIDs)
+	if len(ids) > 0 {
+		query = query.Where("(date >= ? AND date <= ?) OR id IN ?", from, to, ids)
+	} else {
+		query = query.Where("date >= ? AND date <= ?", from, to)
+	}
+	err := query.Order("date asc, id asc").Find(&milestones).Error
+	return milestones, err
+}
+
+// GetDependenciesAmong 获取两端都在taskIDs中的依赖关系
+func GetDependenciesAmong(taskIDs []uint) ([]models.TaskDependency, error) {
+	dependencies := []models.TaskDependency{}
+	if len(taskIDs) == 0 {
+		return dependencies, nil
+	}
+	err := DB.Where("blocker_id IN ? AND blocked_id IN ?", taskIDs, taskIDs).
+		Order("blocker_id asc, blocked_id asc").
+		Find(&dependencies).Error
+	return dependencies, err
+}
@@ -0,0 +1,312 @@
+package repository
+
+import (
+	"errors"
+	"project_management/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TrashFilter 回收站列表的筛选条件
+type TrashFilter struct {
+	ProjectIDs []uint            // 限定的项目范围，为空时不返回任何记录
+	EntityType models.EntityType // 实体类型，为空时同时返回任务和里程碑
+}
+
+// trashSortColumns 回收站列表允许排序的字段
+var trashSortColumns = map[string]string{
+	"deleted_at": "deleted_at",
+	"name":       "name",
+	"project_id": "project_id",
+}
+
+// ListTrash 分页查询回收站中的任务和里程碑，默认按删除时间倒序
+func ListTrash(filter TrashFilter, opts ListOptions) ([]models.TrashItem, int64, error) {
+	items := []models.TrashItem{}
+	if len(filter.ProjectIDs) == 0 {
+		return items, 0, nil
+	}
+
+	tasks := DB.Unscoped().Model(&models.Task{}).
+		Select("'task' AS entity_type, id, project_id, name, deleted_at").
+		Where("project_id IN ? AND deleted_at IS NOT NULL", filter.ProjectIDs)
+	milestones := DB.Unscoped().Model(&models.Milestone{}).
+		Select("'milestone' AS entity_type, id, project_id, title AS name, deleted_at").
+		Where("project_id IN ? AND deleted_at IS NOT NULL", filter.ProjectIDs)
+
+	var source *gorm.DB
+	switch filter.EntityType {
+	case models.EntityTask:
+		source = tasks
+	case models.EntityMilestone:
+		source = milestones
+	default:
+		source = DB.Raw("? UNION ALL ?", tasks, milestones)
+	}
+
+	// 同一查询条件分别用于统计总数和分页查询
+	query := DB.Table("(?) AS trash", source).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	paged, err := paginate(query, opts, trashSortColumns, "deleted_at desc, id desc")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = paged.Scan(&items).Error
+	return items, total, err
+}
+
+// GetDeletedTask 获取回收站中的任务，不存在或未删除时返回nil
+func GetDeletedTask(id uint) (*models.Task, error) {
+	var task models.Task
+	err := DB.Unscoped().Where("deleted_at IS NOT NULL").First(&task, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &task, nil
+}
+
+// GetDeletedMilestone 获取回收站中的里程碑，不存在或未删除时返回nil
+func GetDeletedMilestone(id uint) (*models.Milestone, error) {
+	var milestone models.Milestone
+	err := DB.Unscoped().Where("deleted_at IS NOT NULL").First(&milestone, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &milestone, nil
+}
+
+// RestoreTask 从回收站恢复任务，以及与它同时被级联删除的下级任务，返回恢复的全部任务ID，
+// 以及删除时上移一级、现在移回该任务下的子任务（移回前的数据）。
+// 父任务已不存在、仍在回收站中或已移到其他项目时，任务恢复为顶层任务。
+// 依赖关系在删除时保留，恢复后重新生效，期间另一端已移到其他项目的依赖关系会被删除
+func RestoreTask(task *models.Task) ([]uint, []models.Task, error) {
+	var ids []uint
+	var relinked []models.Task
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		ids = []uint{task.ID}
+		frontier := []uint{task.ID}
+		for len(frontier) > 0 {
+			var children []uint
+			if err := tx.Unscoped().Model(&models.Task{}).
+				Where("parent_id IN ? AND deleted_at = ?", frontier, task.DeletedAt.Time).
+				Pluck("id", &children).Error; err != nil {
+				return err
+			}
+			ids = append(ids, children...)
+			frontier = children
+		}
+
+		if task.ParentID != nil {
+			var count int64
+			if err := tx.Model(&models.Task{}).Where("id = ? AND project_id = ?", *task.ParentID, task.ProjectID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				if err := tx.Unscoped().Model(&models.Task{}).Where("id = ?", task.ID).Updates(map[string]interface{}{
+					"parent_id": nil,
+					"version":   gorm.Expr("version + 1"),
+				}).Error; err != nil {
+					return err
+				}
+				task.ParentID = nil
+				task.Version++
+			}
+		}
+
+		// 删除时上移的子任务移回，期间被移动过父任务、移入回收站或移到其他项目的子任务保持不变
+		if err := tx.Preload("Assignees").
+			Where("trashed_parent_id = ? AND project_id = ?", task.ID, task.ProjectID).
+			Order("id asc").
+			Find(&relinked).Error; err != nil {
+			return err
+		}
+		if len(relinked) > 0 {
+			relinkedIDs := make([]uint, len(relinked))
+			for i := range relinked {
+				relinkedIDs[i] = relinked[i].ID
+			}
+			if err := tx.Model(&models.Task{}).Where("id IN ?", relinkedIDs).Updates(map[string]interface{}{
+				"parent_id": task.ID,
+				"version":   gorm.Expr("version + 1"),
+			}).Error; err != nil {
+				return err
+			}
+		}
+		if err := clearTrashedParent(tx, []uint{task.ID}); err != nil {
+			return err
+		}
+
+		otherProjects := tx.Unscoped().Model(&models.Task{}).Select("id").Where("project_id <> ?", task.ProjectID)
+		if err := tx.Where("(blocker_id IN ? AND blocked_id IN (?)) OR (blocked_id IN ? AND blocker_id IN (?))",
+			ids, otherProjects, ids, otherProjects).Delete(&models.TaskDependency{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Model(&models.Task{}).Where("id IN ?", ids).Update("deleted_at", nil).Error
+	})
+	return ids, relinked, err
+}
+
+// clearTrashedParent 清除任务中记录的已恢复或已彻底删除的父任务
+func clearTrashedParent(tx *gorm.DB, ids []uint) error {
+	return tx.Unscoped().Model(&models.Task{}).Where("trashed_parent_id IN ?", ids).
+		UpdateColumn("trashed_parent_id", nil).Error
+}
+
+// RestoreMilestone 从回收站恢复里程碑，并重新关联删除时解除关联的任务（包括回收站中的任务）。
+// 期间已关联到其他里程碑或移到其他项目的任务保持不变
+func RestoreMilestone(milestone *models.Milestone) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Task{}).
+			Where("trashed_milestone_id = ? AND milestone_id IS NULL AND project_id = ?", milestone.ID, milestone.ProjectID).
+			Updates(map[string]interface{}{
+				"milestone_id": milestone.ID,
+				"version":      gorm.Expr("version + 1"),
+			}).Error; err != nil {
+			return err
+		}
+		if err := clearTrashedMilestone(tx, []uint{milestone.ID}); err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&models.Milestone{}).Where("id = ?", milestone.ID).Update("deleted_at", nil).Error
+	})
+}
+
+// clearTrashedMilestone 清除任务中记录的已恢复或已彻底删除的里程碑
+func clearTrashedMilestone(tx *gorm.DB, ids []uint) error {
+	return tx.Unscoped().Model(&models.Task{}).Where("trashed_milestone_id IN ?", ids).
+		UpdateColumn("trashed_milestone_id", nil).Error
+}
+
+// PurgeDeletedTasks 彻底删除在before之前移入回收站的任务及其负责人、依赖关系、评论、通知和附件记录，
+// 返回删除的任务数和需要从存储后端删除的附件对象键
+func PurgeDeletedTasks(before time.Time) (int, []string, error) {
+	var ids []uint
+	if err := DB.Unscoped().Model(&models.Task{}).Where("deleted_at < ?", before).Pluck("id", &ids).Error; err != nil {
+		return 0, nil, err
+	}
+	if len(ids) == 0 {
+		return 0, nil, nil
+	}
+
+	var keys []string
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Attachment{}).Where("task_id IN ?", ids).Pluck("storage_key", &keys).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Task{}).Where("parent_id IN ?", ids).Updates(map[string]interface{}{
+			"parent_id": nil,
+			"version":   gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		if err := clearTrashedParent(tx, ids); err != nil {
+			return err
+		}
+		if err := tx.Where("task_id IN ?", ids).Delete(&models.TaskAssignee{}).Error; err != nil {
+			return err
+		}
+		if err := deleteTaskDependencies(tx, ids); err != nil {
+			return err
+		}
+		if err := deleteEntityComments(tx, models.EntityTask, ids); err != nil {
+			return err
+		}
+		if err := deleteEntityNotifications(tx, models.EntityTask, ids); err != nil {
+			return err
+		}
+		if err := tx.Where("task_id IN ?", ids).Delete(&models.Attachment{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id IN ?", ids).Delete(&models.Task{}).Error
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return len(ids), keys, nil
+}
+
+// PurgeDeletedMilestones 彻底删除在before之前移入回收站的里程碑及其评论和通知，返回删除的里程碑数
+func PurgeDeletedMilestones(before time.Time) (int, error) {
+	var ids []uint
+	if err := DB.Unscoped().Model(&models.Milestone{}).Where("deleted_at < ?", before).Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := clearTrashedMilestone(tx, ids); err != nil {
+			return err
+		}
+		if err := deleteEntityComments(tx, models.EntityMilestone, ids); err != nil {
+			return err
+		}
+		if err := deleteEntityNotifications(tx, models.EntityMilestone, ids); err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id IN ?", ids).Delete(&models.Milestone{}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// PurgeDeletedUsers 彻底删除在before之前注销的用户及其项目成员身份、任务负责人、评论提及、刷新令牌、通知和邮件记录，
+// 用户发表的评论和操作记录保留，返回删除的用户数
+func PurgeDeletedUsers(before time.Time) (int, error) {
+	var ids []uint
+	if err := DB.Unscoped().Model(&models.User{}).Where("deleted_at < ?", before).Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id IN ?", ids).Delete(&models.ProjectMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id IN ?", ids).Delete(&models.TaskAssignee{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id IN ?", ids).Delete(&models.CommentMention{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id IN ?", ids).Delete(&models.RefreshToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id IN ?", ids).Delete(&models.Notification{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id IN ?", ids).Delete(&models.NotificationPreference{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id IN ?", ids).Delete(&models.EmailVerification{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id IN ?", ids).Delete(&models.EmailDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id IN ?", ids).Delete(&models.User{}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
@@ -25,6 +25,16 @@ func GetUserByID(id uint) (*models.User, error) {
 	return &user, nil
 }
 
+// GetUsersByIDs 批量获取用户
+func GetUsersByIDs(ids []uint) ([]models.User, error) {
+	users := []models.User{}
+	if len(ids) == 0 {
+		return users, nil
+	}
+	err := DB.Where("id IN ?", ids).Order("id asc").Find(&users).Error
+	return users, err
+}
+
 // GetUserByUsername 通过用户名获取用户
 func GetUserByUsername(username string) (*models.User, error) {
 	var user models.User
@@ -38,14 +48,47 @@ func GetUserByUsername(username string) (*models.User, error) {
 	return &user, nil
 }
 
+// GetDeletedUserByUsername 通过用户名获取已注销的用户
+func GetDeletedUserByUsername(username string) (*models.User, error) {
+	var user models.User
+	err := DB.Unscoped().Where("username = ? AND deleted_at IS NOT NULL", username).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UsernameExists 检查用户名是否已被使用，包括已注销但尚未彻底删除的用户
+func UsernameExists(username string) (bool, error) {
+	var count int64
+	err := DB.Unscoped().Model(&models.User{}).Where("username = ?", username).Count(&count).Error
+	return count > 0, err
+}
+
+// RestoreUser 恢复已注销的用户
+func RestoreUser(id uint) error {
+	return DB.Unscoped().Model(&models.User{}).Where("id = ?", id).Update("deleted_at", nil).Error
+}
+
 // UpdateUser 更新用户信息
 func UpdateUser(user *models.User) error {
 	return DB.Save(user).Error
 }
 
-// DeleteUser 删除用户
+// DeleteUser 注销用户并删除其刷新令牌和尚未发送的邮件，用户数据保留到超过回收站保留期后彻底删除
 func DeleteUser(id uint) error {
-	return DB.Delete(&models.User{}, id).Error
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", id).Delete(&models.RefreshToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ? AND status = ?", id, models.EmailPending).Delete(&models.EmailDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.User{}, id).Error
+	})
 }
 
 // GetTotalUserCount 获取用户总数
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrVersionConflict 数据在读取后已被其他请求修改
+var ErrVersionConflict = errors.New("数据已被其他人修改")
+
+// saveWithVersion 以乐观锁方式保存model的全部字段：仅当数据库中的版本号仍等于*version时才更新，
+// 同时将版本号加一。版本号不一致或记录已被删除时返回ErrVersionConflict，且*version保持不变
+func saveWithVersion(tx *gorm.DB, model interface{}, version *uint, omit ...string) error {
+	expected := *version
+	*version = expected + 1
+	result := tx.Model(model).Select("*").Omit(append(omit, "CreatedAt", "DeletedAt")...).
+		Where("version = ?", expected).Updates(model)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = ErrVersionConflict
+	}
+	if result.Error != nil {
+		*version = expected
+	}
+	return result.Error
+}
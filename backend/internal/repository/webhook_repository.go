@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"errors"
+	"project_management/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CreateWebhook 创建Webhook
+func CreateWebhook(webhook *models.Webhook) error {
+	return DB.Create(webhook).Error
+}
+
+// GetProjectWebhooks 获取项目的全部Webhook
+func GetProjectWebhooks(projectID uint) ([]models.Webhook, error) {
+	webhooks := []models.Webhook{}
+	err := DB.Where("project_id = ?", projectID).Order("id asc").Find(&webhooks).Error
+	return webhooks, err
+}
+
+// GetActiveWebhooks 获取项目中已启用的Webhook
+func GetActiveWebhooks(projectID uint) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := DB.Where("project_id = ? AND active = ?", projectID, true).Find(&webhooks).Error
+	return webhooks, err
+}
+
+// GetProjectWebhook 获取项目中的Webhook
+func GetProjectWebhook(projectID, id uint) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := DB.Where("project_id = ?", projectID).First(&webhook, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// UpdateWebhook 更新Webhook
+func UpdateWebhook(webhook *models.Webhook) error {
+	return DB.Save(webhook).Error
+}
+
+// DeleteWebhook 删除Webhook及其投递记录
+func DeleteWebhook(id uint) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Webhook{}, id).Error
+	})
+}
+
+// deleteProjectWebhooks 删除项目的全部Webhook及其投递记录
+func deleteProjectWebhooks(tx *gorm.DB, projectID uint) error {
+	webhookIDs := tx.Model(&models.Webhook{}).Select("id").Where("project_id = ?", projectID)
+	if err := tx.Where("webhook_id IN (?)", webhookIDs).Delete(&models.WebhookDelivery{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("project_id = ?", projectID).Delete(&models.Webhook{}).Error
+}
+
+// CreateWebhookDeliveries 批量创建投递记录
+func CreateWebhookDeliveries(deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return DB.Create(&deliveries).Error
+}
+
+// WebhookDeliveryFilter 投递记录列表的筛选条件
+type WebhookDeliveryFilter struct {
+	WebhookID uint                           // Webhook ID
+	Statuses  []models.WebhookDeliveryStatus // 投递状态
+	Events    []models.WebhookEvent          // 事件类型
+}
+
+// webhookDeliverySortColumns 投递记录列表允许排序的字段
+var webhookDeliverySortColumns = map[string]string{
+	"id":              "id",
+	"created_at":      "created_at",
+	"last_attempt_at": "last_attempt_at",
+}
+
+// ListWebhookDeliveries 按条件分页查询投递记录，返回当前页的记录和符合条件的总数
+func ListWebhookDeliveries(filter WebhookDeliveryFilter, opts ListOptions) ([]models.WebhookDelivery, int64, error) {
+	deliveries := []models.WebhookDelivery{}
+
+	query := DB.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", filter.WebhookID)
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if len(filter.Events) > 0 {
+		query = query.Where("event IN ?", filter.Events)
+	}
+
+	// 同一查询条件分别用于统计总数和分页查询
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	paged, err := paginate(query, opts, webhookDeliverySortColumns, "created_at desc, id desc")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = paged.Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+// GetWebhookDelivery 获取Webhook的投递记录
+func GetWebhookDelivery(webhookID, id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := DB.Where("webhook_id = ?", webhookID).First(&delivery, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// GetDueWebhookDeliveries 获取已到投递时间的待投递记录，已停用的Webhook的投递记录在重新启用前保持等待
+func GetDueWebhookDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := DB.Preload("Webhook").
+		Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+		Where("webhook_id IN (?)", DB.Model(&models.Webhook{}).Select("id").Where("active = ?", true)).
+		Order("next_attempt_at asc, id asc").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// SaveWebhookDelivery 保存投递记录
+func SaveWebhookDelivery(delivery *models.WebhookDelivery) error {
+	return DB.Omit("Webhook").Save(delivery).Error
+}
+
+// DeleteFinishedWebhookDeliveries 删除在before之前创建且已结束的投递记录，返回删除的数量
+func DeleteFinishedWebhookDeliveries(before time.Time) (int64, error) {
+	result := DB.Where("status <> ? AND created_at < ?", models.DeliveryPending, before).
+		Delete(&models.WebhookDelivery{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,165 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"os"
+	"project_management/internal/mail"
+	"project_management/internal/models"
+	"project_management/internal/notification"
+	"project_management/internal/repository"
+	"project_management/internal/storage"
+	"project_management/internal/stream"
+	"project_management/internal/webhook"
+	"project_management/internal/workflow"
+	"time"
+)
+
+// MarkOverdueTasks 将已过截止日期且未完成的任务自动标记为已延期，只处理状态机允许流转到已延期的状态，
+// 并以系统身份记录操作
+func MarkOverdueTasks(ctx context.Context) error {
+	var fromStatuses []models.TaskStatus
+	for _, status := range models.TaskStatuses {
+		if status != models.TaskStatusCompleted && status != models.TaskStatusDelayed &&
+			workflow.Current().CanTransition(status, models.TaskStatusDelayed) {
+			fromStatuses = append(fromStatuses, status)
+		}
+	}
+
+	tasks, err := repository.MarkOverdueTasksDelayed(models.OverdueCutoff(), fromStatuses)
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	// 以系统身份记录状态变更
+	ids := make([]uint, len(tasks))
+	activities := make([]models.Activity, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+		activities[i] = models.Activity{
+			ProjectID:  task.ProjectID,
+			EntityType: models.EntityTask,
+			EntityID:   task.ID,
+			Action:     models.ActivityUpdate,
+			Changes: models.FieldChanges{
+				"status": {Before: task.Status, After: models.TaskStatusDelayed},
+			},
+		}
+	}
+	log.Printf("已将 %d 个逾期任务自动标记为已延期: %v", len(tasks), ids)
+
+	if err := repository.CreateActivities(activities); err != nil {
+		return err
+	}
+	stream.Current().Publish(activities...)
+
+	// 通知订阅了任务变更的Webhook和任务负责人
+	for i := range activities {
+		task, err := repository.GetTaskByID(activities[i].EntityID)
+		if err != nil {
+			return err
+		}
+		if task == nil {
+			continue
+		}
+		data := models.TaskSnapshot(task)
+		if err := webhook.Enqueue(&activities[i], data); err != nil {
+			log.Printf("创建Webhook投递失败: task #%d: %v", task.ID, err)
+		}
+		if err := notification.FromActivity(&activities[i], data); err != nil {
+			log.Printf("创建通知失败: task #%d: %v", task.ID, err)
+		}
+	}
+	return nil
+}
+
+// CleanupExpiredTokens 清理数据库中已过期的刷新令牌
+func CleanupExpiredTokens(ctx context.Context) error {
+	return repository.DeleteExpiredTokens()
+}
+
+// PurgeTrash 彻底删除超过回收站保留期的任务、里程碑和已注销的用户，并从存储后端删除任务附件
+func PurgeTrash(ctx context.Context) error {
+	before := time.Now().Add(-models.TrashRetention())
+
+	tasks, keys, err := repository.PurgeDeletedTasks(before)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := storage.Current().Delete(ctx, key); err != nil {
+			log.Printf("删除附件文件失败: %s: %v", key, err)
+		}
+	}
+
+	milestones, err := repository.PurgeDeletedMilestones(before)
+	if err != nil {
+		return err
+	}
+
+	users, err := repository.PurgeDeletedUsers(before)
+	if err != nil {
+		return err
+	}
+
+	if tasks > 0 || milestones > 0 || users > 0 {
+		log.Printf("已彻底删除回收站中的 %d 个任务、%d 个里程碑和 %d 个已注销用户", tasks, milestones, users)
+	}
+	return nil
+}
+
+// DeliverWebhooks 投递已到时间的Webhook通知
+func DeliverWebhooks(ctx context.Context) error {
+	return webhook.DeliverDue(ctx)
+}
+
+// CleanupWebhookDeliveries 删除超过保留期且已结束的Webhook投递记录
+func CleanupWebhookDeliveries(ctx context.Context) error {
+	deleted, err := repository.DeleteFinishedWebhookDeliveries(time.Now().Add(-models.WebhookDeliveryRetention()))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Printf("已删除 %d 条过期的Webhook投递记录", deleted)
+	}
+	return nil
+}
+
+// DeadlineReminders 向即将截止的任务的负责人发送截止提醒
+func DeadlineReminders(ctx context.Context) error {
+	return notification.DeadlineReminders(ctx)
+}
+
+// SendEmails 发送邮件队列中已到时间的邮件
+func SendEmails(ctx context.Context) error {
+	return mail.DeliverDue(ctx)
+}
+
+// SendDigests 向订阅了摘要的用户发送任务和里程碑摘要邮件
+func SendDigests(ctx context.Context) error {
+	return notification.SendDigests(ctx)
+}
+
+// CleanupEmailDeliveries 删除超过保留期且已结束的邮件记录
+func CleanupEmailDeliveries(ctx context.Context) error {
+	deleted, err := repository.DeleteFinishedEmailDeliveries(time.Now().Add(-models.EmailRetention()))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Printf("已删除 %d 条过期的邮件记录", deleted)
+	}
+	return nil
+}
+
+// IntervalFromEnv 从环境变量读取任务执行间隔，未设置或格式错误时使用默认值
+func IntervalFromEnv(key string, fallback time.Duration) time.Duration {
+	interval, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || interval <= 0 {
+		return fallback
+	}
+	return interval
+}
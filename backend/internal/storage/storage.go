@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+// ErrNotFound 对象不存在
+var ErrNotFound = errors.New("文件不存在")
+
+// Storage 附件存储后端
+type Storage interface {
+	// Put 写入对象，size为内容长度，已存在时覆盖
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get 读取对象，对象不存在时返回ErrNotFound，调用方负责关闭返回的Reader
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete 删除对象，对象不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+}
+
+// current 当前使用的存储后端，可通过Init从环境变量配置
+var current Storage = NewLocal("./uploads")
+
+// Init 根据环境变量STORAGE_DRIVER初始化存储后端，支持local（默认）和s3
+func Init() {
+	switch driver := strings.ToLower(os.Getenv("STORAGE_DRIVER")); driver {
+	case "", "local":
+		dir := os.Getenv("STORAGE_LOCAL_DIR")
+		if dir == "" {
+			dir = "./uploads"
+		}
+		current = NewLocal(dir)
+		log.Printf("附件存储使用本地目录: %s", dir)
+	case "s3":
+		s3, err := NewS3(S3Config{
+			Endpoint:       os.Getenv("S3_ENDPOINT"),
+			Region:         os.Getenv("S3_REGION"),
+			Bucket:         os.Getenv("S3_BUCKET"),
+			AccessKey:      os.Getenv("S3_ACCESS_KEY"),
+			SecretKey:      os.Getenv("S3_SECRET_KEY"),
+			ForcePathStyle: os.Getenv("S3_FORCE_PATH_STYLE") == "true",
+		})
+		if err != nil {
+			log.Fatalf("初始化S3存储失败: %v", err)
+		}
+		current = s3
+		log.Printf("附件存储使用S3存储桶: %s", s3.bucket)
+	default:
+		log.Fatalf("不支持的存储类型: %s", driver)
+	}
+}
+
+// Current 获取当前使用的存储后端
+func Current() Storage {
+	return current
+}
@@ -0,0 +1,90 @@
+package stream
+
+import (
+	"project_management/internal/models"
+	"sync"
+)
+
+// 每个订阅者缓冲的事件数，缓冲区满时断开该订阅者，由客户端重连后从Last-Event-ID补发
+const subscriberBuffer = 100
+
+// Subscriber 事件流的订阅者，Events在订阅被取消、缓冲区溢出或服务关闭时关闭
+type Subscriber struct {
+	Events chan models.Activity
+}
+
+// Broker 进程内的事件分发器，将任务和里程碑的操作记录广播给所有订阅者
+type Broker struct {
+	mu          sync.Mutex
+	subscribers map[*Subscriber]struct{}
+	closed      bool
+}
+
+// current 当前进程使用的分发器
+var current = NewBroker()
+
+// NewBroker 创建事件分发器
+func NewBroker() *Broker {
+	return &Broker{subscribers: make(map[*Subscriber]struct{})}
+}
+
+// Current 获取当前进程使用的分发器
+func Current() *Broker {
+	return current
+}
+
+// Subscribe 添加订阅者，分发器已关闭时返回的订阅者的Events已关闭
+func (b *Broker) Subscribe() *Subscriber {
+	sub := &Subscriber{Events: make(chan models.Activity, subscriberBuffer)}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(sub.Events)
+		return sub
+	}
+	b.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe 取消订阅，可以重复调用
+func (b *Broker) Unsubscribe(sub *Subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(sub)
+}
+
+// Publish 将操作记录发送给所有订阅者，不会阻塞；缓冲区已满的订阅者会被断开
+func (b *Broker) Publish(activities ...models.Activity) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subscribers {
+		for _, activity := range activities {
+			select {
+			case sub.Events <- activity:
+				continue
+			default:
+			}
+			b.remove(sub)
+			break
+		}
+	}
+}
+
+// Close 关闭分发器并断开所有订阅者，用于服务关闭时结束长连接
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subscribers {
+		b.remove(sub)
+	}
+	b.closed = true
+}
+
+// remove 移除订阅者并关闭其Events，调用方需持有锁
+func (b *Broker) remove(sub *Subscriber) {
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.Events)
+	}
+}
@@ -0,0 +1,372 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"project_management/internal/models"
+	"project_management/internal/repository"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// 投递重试策略：第n次失败后等待 retryBaseDelay*2^(n-1)，最长 retryMaxDelay，共尝试 MaxAttempts 次
+const (
+	MaxAttempts    = 10
+	retryBaseDelay = 30 * time.Second
+	retryMaxDelay  = time.Hour
+)
+
+// 每次定时任务最多投递的记录数
+const deliveryBatchSize = 50
+
+// 投递请求的默认超时时间，可通过环境变量WEBHOOK_TIMEOUT覆盖
+const defaultTimeout = 10 * time.Second
+
+// 投递请求的请求头
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Payload 投递请求的请求体
+type Payload struct {
+	Event      models.WebhookEvent `json:"event"`
+	OccurredAt time.Time           `json:"occurred_at"`
+	ProjectID  uint                `json:"project_id"`
+	ActorID    *uint               `json:"actor_id"`              // 为空表示系统操作
+	EntityType models.EntityType   `json:"entity_type,omitempty"` // ping事件为空
+	EntityID   uint                `json:"entity_id,omitempty"`   // 任务或里程碑ID
+	ActivityID uint                `json:"activity_id,omitempty"` // 对应的操作记录ID
+	Changes    models.FieldChanges `json:"changes,omitempty"`     // 字段级的变更前后值
+	Data       models.Snapshot     `json:"data,omitempty"`        // 操作后的字段，删除时为删除前的字段
+	WebhookID  uint                `json:"webhook_id,omitempty"`  // 仅ping事件
+}
+
+// ErrForbiddenAddress Webhook地址指向本机、内网或其他保留地址
+var ErrForbiddenAddress = errors.New("不允许向本机或内网地址发送Webhook")
+
+// 除本机、私有、链路本地、未指定和组播地址外禁止连接的网段
+var forbiddenNetworks = parseNetworks("0.0.0.0/8,100.64.0.0/10,192.0.0.0/24,198.18.0.0/15,240.0.0.0/4,64:ff9b::/96")
+
+// allowedNetworks 允许连接的内网网段，由环境变量WEBHOOK_ALLOWED_NETWORKS配置（逗号分隔的CIDR或IP）
+var allowedNetworks = parseNetworks(os.Getenv("WEBHOOK_ALLOWED_NETWORKS"))
+
+// client 发送投递请求的HTTP客户端，不跟随重定向、不使用代理，并在建立连接时检查目标地址，
+// 避免通过DNS重新绑定绕过创建时的检查
+var client = &http.Client{
+	Timeout: timeoutFromEnv(),
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: defaultTimeout,
+			Control: checkDial,
+		}).DialContext,
+		TLSHandshakeTimeout: defaultTimeout,
+		MaxIdleConnsPerHost: 2,
+		IdleConnTimeout:     90 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// timeoutFromEnv 读取投递请求的超时时间
+func timeoutFromEnv() time.Duration {
+	timeout, err := time.ParseDuration(os.Getenv("WEBHOOK_TIMEOUT"))
+	if err != nil || timeout <= 0 {
+		return defaultTimeout
+	}
+	return timeout
+}
+
+// parseNetworks 解析逗号分隔的CIDR或IP列表，忽略无法解析的项
+func parseNetworks(value string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !strings.Contains(item, "/") {
+			if ip := net.ParseIP(item); ip != nil && ip.To4() != nil {
+				item += "/32"
+			} else {
+				item += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			log.Printf("忽略无效的网段 %q: %v", item, err)
+			continue
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// CheckIP 检查是否允许向该IP发送Webhook，本机、私有、链路本地、未指定、组播和其他保留地址
+// 只有在WEBHOOK_ALLOWED_NETWORKS中时才允许
+func CheckIP(ip net.IP) error {
+	for _, network := range allowedNetworks {
+		if network.Contains(ip) {
+			return nil
+		}
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified() || ip.IsMulticast() {
+		return ErrForbiddenAddress
+	}
+	for _, network := range forbiddenNetworks {
+		if network.Contains(ip) {
+			return ErrForbiddenAddress
+		}
+	}
+	return nil
+}
+
+// CheckHost 创建Webhook时检查URL中的主机，主机为IP或localhost时按CheckIP检查，
+// 域名在每次连接时按解析出的IP检查
+func CheckHost(host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrForbiddenAddress
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return CheckIP(ip)
+	}
+	return nil
+}
+
+// checkDial 在建立连接前检查解析后的目标IP
+func checkDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("无效的目标地址 %s", address)
+	}
+	if err := CheckIP(ip); err != nil {
+		return fmt.Errorf("%w: %s", err, ip)
+	}
+	return nil
+}
+
+// NewSecret 生成随机的签名密钥
+func NewSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// Sign 计算请求签名：以密钥对"时间戳.请求体"做HMAC-SHA256，结果为"sha256="加十六进制摘要
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Enqueue 为项目中订阅了操作记录对应事件的Webhook创建待投递记录，data为操作后（删除时为操作前）的字段快照
+func Enqueue(activity *models.Activity, data models.Snapshot) error {
+	events := models.WebhookEventsFor(activity)
+
+	webhooks, err := repository.GetActiveWebhooks(activity.ProjectID)
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+
+	var deliveries []models.WebhookDelivery
+	for _, event := range events {
+		body, err := json.Marshal(Payload{
+			Event:      event,
+			OccurredAt: activity.CreatedAt,
+			ProjectID:  activity.ProjectID,
+			ActorID:    activity.ActorID,
+			EntityType: activity.EntityType,
+			EntityID:   activity.EntityID,
+			ActivityID: activity.ID,
+			Changes:    activity.Changes,
+			Data:       data,
+		})
+		if err != nil {
+			return err
+		}
+		for _, webhook := range webhooks {
+			if webhook.Events.Has(event) {
+				deliveries = append(deliveries, newDelivery(webhook.ID, event, body))
+			}
+		}
+	}
+	return repository.CreateWebhookDeliveries(deliveries)
+}
+
+// Ping 为Webhook创建一条测试投递记录，无论是否订阅都会发送
+func Ping(webhook *models.Webhook) (*models.WebhookDelivery, error) {
+	body, err := json.Marshal(Payload{
+		Event:      models.WebhookPing,
+		OccurredAt: time.Now(),
+		ProjectID:  webhook.ProjectID,
+		WebhookID:  webhook.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	deliveries := []models.WebhookDelivery{newDelivery(webhook.ID, models.WebhookPing, body)}
+	if err := repository.CreateWebhookDeliveries(deliveries); err != nil {
+		return nil, err
+	}
+	return &deliveries[0], nil
+}
+
+// Redeliver 将投递记录重新放入队列，尝试次数从零开始计算
+func Redeliver(delivery *models.WebhookDelivery) error {
+	now := time.Now()
+	delivery.Status = models.DeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = &now
+	return repository.SaveWebhookDelivery(delivery)
+}
+
+// newDelivery 创建立即投递的待投递记录
+func newDelivery(webhookID uint, event models.WebhookEvent, body []byte) models.WebhookDelivery {
+	now := time.Now()
+	return models.WebhookDelivery{
+		WebhookID:     webhookID,
+		Event:         event,
+		Payload:       body,
+		Status:        models.DeliveryPending,
+		NextAttemptAt: &now,
+	}
+}
+
+// DeliverDue 投递已到时间的待投递记录，失败的记录按指数退避安排重试
+func DeliverDue(ctx context.Context) error {
+	deliveries, err := repository.GetDueWebhookDeliveries(time.Now(), deliveryBatchSize)
+	if err != nil {
+		return err
+	}
+
+	succeeded, failed := 0, 0
+	for i := range deliveries {
+		if ctx.Err() != nil {
+			break
+		}
+		delivery := &deliveries[i]
+		attempt(ctx, delivery)
+		if ctx.Err() != nil {
+			// 服务关闭时中断的投递不计入尝试次数，保持等待状态
+			break
+		}
+		if err := repository.SaveWebhookDelivery(delivery); err != nil {
+			return err
+		}
+		if delivery.Status == models.DeliverySucceeded {
+			succeeded++
+		} else {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		log.Printf("Webhook投递完成: %d 个成功，%d 个失败", succeeded, failed)
+	}
+	return nil
+}
+
+// attempt 发送一次投递请求，并根据结果更新投递记录的状态和下次尝试时间
+func attempt(ctx context.Context, delivery *models.WebhookDelivery) {
+	start := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &start
+	delivery.ResponseStatus = 0
+	delivery.Error = ""
+
+	err := send(ctx, delivery)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+
+	if err == nil {
+		delivery.Status = models.DeliverySucceeded
+		delivery.NextAttemptAt = nil
+		return
+	}
+
+	delivery.Error = truncate(err.Error(), 500)
+	if delivery.Attempts >= MaxAttempts {
+		delivery.Status = models.DeliveryFailed
+		delivery.NextAttemptAt = nil
+		return
+	}
+	next := time.Now().Add(retryDelay(delivery.Attempts))
+	delivery.NextAttemptAt = &next
+}
+
+// send 发送签名的投递请求，接收方返回2xx以外的状态码时返回错误
+func send(ctx context.Context, delivery *models.WebhookDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "project-management-webhook/1.0")
+	req.Header.Set(HeaderEvent, string(delivery.Event))
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	// 不读取响应内容，只记录状态码
+	resp.Body.Close()
+	delivery.ResponseStatus = resp.StatusCode
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("接收方返回状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// retryDelay 计算第attempts次失败后到下次尝试的等待时间
+func retryDelay(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= retryMaxDelay {
+			return retryMaxDelay
+		}
+	}
+	return delay
+}
+
+// truncate 截断过长的文本，保证不截断多字节字符
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && s[max]&0xC0 == 0x80 {
+		max--
+	}
+	return s[:max]
+}
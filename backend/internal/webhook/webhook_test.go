@@ -0,0 +1,214 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"project_management/internal/models"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	// 期望值由 HMAC-SHA256("whsec_test", `1700000000.{"event":"ping"}`) 独立计算得出
+	want := "sha256=aa8efe37b751e71157c508c5ac4acb1e9fe5225db98355dfc00f4b680afbc447"
+	if got := Sign("whsec_test", 1700000000, []byte(`{"event":"ping"}`)); got != want {
+		t.Errorf("Sign() = %s, want %s", got, want)
+	}
+
+	// 密钥、时间戳或请求体任一不同时签名都不同
+	signatures := map[string]bool{}
+	for _, sig := range []string{
+		Sign("whsec_test", 1700000000, []byte(`{"event":"ping"}`)),
+		Sign("whsec_other", 1700000000, []byte(`{"event":"ping"}`)),
+		Sign("whsec_test", 1700000001, []byte(`{"event":"ping"}`)),
+		Sign("whsec_test", 1700000000, []byte(`{"event":"pong"}`)),
+	} {
+		signatures[sig] = true
+	}
+	if len(signatures) != 4 {
+		t.Errorf("got %d distinct signatures, want 4", len(signatures))
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{MaxAttempts, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	tests := []struct {
+		host    string
+		allowed string
+		wantErr bool
+	}{
+		{"example.com", "", false},
+		{"93.184.216.34", "", false},
+		{"2606:2800:220:1:248:1893:25c8:1946", "", false},
+		{"localhost", "", true},
+		{"LOCALHOST.", "", true},
+		{"api.localhost", "", true},
+		{"127.0.0.1", "", true},
+		{"::1", "", true},
+		{"10.1.2.3", "", true},
+		{"172.16.0.1", "", true},
+		{"192.168.1.1", "", true},
+		{"169.254.169.254", "", true},
+		{"::ffff:169.254.169.254", "", true},
+		{"fe80::1", "", true},
+		{"fd00::1", "", true},
+		{"0.0.0.0", "", true},
+		{"100.64.0.1", "", true},
+		{"224.0.0.1", "", true},
+		{"10.1.2.3", "10.1.0.0/16", false},
+		{"10.2.0.1", "10.1.0.0/16", true},
+		{"127.0.0.1", "127.0.0.1", false},
+		// 白名单不适用于localhost主机名
+		{"localhost", "127.0.0.1", true},
+	}
+	for _, tt := range tests {
+		withAllowedNetworks(t, tt.allowed)
+		err := CheckHost(tt.host)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CheckHost(%q) with allowed %q error = %v, want error %v", tt.host, tt.allowed, err, tt.wantErr)
+		}
+	}
+}
+
+func TestAttempt(t *testing.T) {
+	payload := []byte(`{"event":"task.created"}`)
+	var status atomic.Int32
+	type request struct {
+		header http.Header
+		path   string
+		body   []byte
+	}
+	received := make(chan request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- request{r.Header, r.URL.Path, body}
+		if status.Load() == http.StatusFound {
+			w.Header().Set("Location", "http://example.com/")
+		}
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+	withAllowedNetworks(t, "127.0.0.1")
+
+	tests := []struct {
+		name         string
+		status       int
+		attempts     int
+		wantStatus   models.WebhookDeliveryStatus
+		wantNextIn   time.Duration
+		wantAttempts int
+	}{
+		{"接收成功", http.StatusNoContent, 0, models.DeliverySucceeded, 0, 1},
+		{"失败后等待重试", http.StatusInternalServerError, 0, models.DeliveryPending, 30 * time.Second, 1},
+		{"按指数退避", http.StatusBadGateway, 2, models.DeliveryPending, 2 * time.Minute, 3},
+		{"不跟随重定向", http.StatusFound, 0, models.DeliveryPending, 30 * time.Second, 1},
+		{"超过最大尝试次数", http.StatusInternalServerError, MaxAttempts - 1, models.DeliveryFailed, 0, MaxAttempts},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status.Store(int32(tt.status))
+			delivery := &models.WebhookDelivery{
+				ID:       42,
+				Event:    models.WebhookEvent("task.created"),
+				Payload:  payload,
+				Status:   models.DeliveryPending,
+				Attempts: tt.attempts,
+				Webhook:  &models.Webhook{URL: server.URL + "/hook", Secret: "whsec_test"},
+			}
+
+			before := time.Now()
+			attempt(context.Background(), delivery)
+
+			r := <-received
+			timestamp, err := strconv.ParseInt(r.header.Get(HeaderTimestamp), 10, 64)
+			if err != nil {
+				t.Fatalf("invalid timestamp header %q", r.header.Get(HeaderTimestamp))
+			}
+			if got, want := r.header.Get(HeaderSignature), Sign("whsec_test", timestamp, payload); got != want || string(r.body) != string(payload) {
+				t.Errorf("received signature %s body %s, want %s %s", got, r.body, want, payload)
+			}
+			if r.header.Get(HeaderEvent) != "task.created" || r.header.Get(HeaderDelivery) != "42" || r.path != "/hook" {
+				t.Errorf("received event %q delivery %q path %q", r.header.Get(HeaderEvent), r.header.Get(HeaderDelivery), r.path)
+			}
+
+			if delivery.Status != tt.wantStatus || delivery.Attempts != tt.wantAttempts {
+				t.Errorf("status = %s attempts = %d, want %s %d", delivery.Status, delivery.Attempts, tt.wantStatus, tt.wantAttempts)
+			}
+			if delivery.ResponseStatus != tt.status {
+				t.Errorf("ResponseStatus = %d, want %d", delivery.ResponseStatus, tt.status)
+			}
+			if (delivery.Error == "") != (tt.wantStatus == models.DeliverySucceeded) {
+				t.Errorf("Error = %q", delivery.Error)
+			}
+			if tt.wantNextIn == 0 {
+				if delivery.NextAttemptAt != nil {
+					t.Errorf("NextAttemptAt = %v, want nil", delivery.NextAttemptAt)
+				}
+			} else if delivery.NextAttemptAt == nil || delivery.NextAttemptAt.Before(before.Add(tt.wantNextIn)) ||
+				delivery.NextAttemptAt.After(time.Now().Add(tt.wantNextIn)) {
+				t.Errorf("NextAttemptAt = %v, want about %v from now", delivery.NextAttemptAt, tt.wantNextIn)
+			}
+		})
+	}
+}
+
+func TestAttemptRejectsForbiddenAddress(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer server.Close()
+	withAllowedNetworks(t, "")
+
+	// 使用解析到本机的域名，模拟创建时检查通过、连接时才解析到内网地址的情况
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	delivery := &models.WebhookDelivery{
+		Event:   models.WebhookPing,
+		Payload: []byte(`{}`),
+		Status:  models.DeliveryPending,
+		Webhook: &models.Webhook{URL: "http://localhost:" + port, Secret: "whsec_test"},
+	}
+	attempt(context.Background(), delivery)
+
+	if hits.Load() != 0 {
+		t.Errorf("receiver got %d requests, want none", hits.Load())
+	}
+	if delivery.Status != models.DeliveryPending || delivery.ResponseStatus != 0 {
+		t.Errorf("status = %s response = %d, want pending without response", delivery.Status, delivery.ResponseStatus)
+	}
+	if !strings.Contains(delivery.Error, ErrForbiddenAddress.Error()) {
+		t.Errorf("Error = %q, want %q", delivery.Error, ErrForbiddenAddress)
+	}
+}
+
+// withAllowedNetworks 在测试期间替换允许连接的内网网段
+func withAllowedNetworks(t *testing.T, value string) {
+	t.Helper()
+	previous := allowedNetworks
+	allowedNetworks = parseNetworks(value)
+	t.Cleanup(func() { allowedNetworks = previous })
+}
@@ -0,0 +1,143 @@
+package workflow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"project_management/internal/models"
+	"time"
+)
+
+var (
+	ErrUnknownStatus     = errors.New("未知的任务状态")
+	ErrInvalidTransition = errors.New("不允许的状态变更")
+)
+
+// DefaultTransitions 默认的任务状态流转规则：已完成的任务只能重新打开为进行中
+var DefaultTransitions = map[models.TaskStatus][]models.TaskStatus{
+	models.TaskStatusPending:   {models.TaskStatusInProcess, models.TaskStatusCompleted, models.TaskStatusDelayed},
+	models.TaskStatusInProcess: {models.TaskStatusPending, models.TaskStatusCompleted, models.TaskStatusDelayed},
+	models.TaskStatusDelayed:   {models.TaskStatusPending, models.TaskStatusInProcess, models.TaskStatusCompleted},
+	models.TaskStatusCompleted: {models.TaskStatusInProcess},
+}
+
+// Workflow 任务状态机
+type Workflow struct {
+	transitions map[models.TaskStatus][]models.TaskStatus
+}
+
+// current 当前生效的状态机，可通过Init从环境变量加载
+var current = &Workflow{transitions: DefaultTransitions}
+
+// New 根据流转规则创建状态机，规则中只能出现合法的任务状态
+func New(transitions map[models.TaskStatus][]models.TaskStatus) (*Workflow, error) {
+	for from, targets := range transitions {
+		if !from.IsValid() {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownStatus, from)
+		}
+		for _, to := range targets {
+			if !to.IsValid() {
+				return nil, fmt.Errorf("%w: %s", ErrUnknownStatus, to)
+			}
+		}
+	}
+	return &Workflow{transitions: transitions}, nil
+}
+
+// Init 从环境变量TASK_WORKFLOW加载状态流转规则，格式为JSON对象，
+// 例如 {"待处理":["进行中"],"进行中":["已完成"],"已完成":["进行中"]}。未设置时使用默认规则。
+func Init() {
+	config := os.Getenv("TASK_WORKFLOW")
+	if config == "" {
+		return
+	}
+
+	var transitions map[models.TaskStatus][]models.TaskStatus
+	if err := json.Unmarshal([]byte(config), &transitions); err != nil {
+		log.Fatalf("解析TASK_WORKFLOW失败: %v", err)
+	}
+
+	w, err := New(transitions)
+	if err != nil {
+		log.Fatalf("TASK_WORKFLOW配置无效: %v", err)
+	}
+	current = w
+	log.Println("已加载自定义任务状态流转规则")
+}
+
+// Current 获取当前生效的状态机
+func Current() *Workflow {
+	return current
+}
+
+// Transitions 返回完整的状态流转规则
+func (w *Workflow) Transitions() map[models.TaskStatus][]models.TaskStatus {
+	return w.transitions
+}
+
+// AllowedTransitions 返回从指定状态可以流转到的状态
+func (w *Workflow) AllowedTransitions(from models.TaskStatus) []models.TaskStatus {
+	return w.transitions[from]
+}
+
+// CanTransition 检查是否允许从from流转到to，状态不变时总是允许
+func (w *Workflow) CanTransition(from, to models.TaskStatus) bool {
+	if from == to {
+		return true
+	}
+	for _, target := range w.transitions[from] {
+		if target == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Initialize 为新建任务设置初始状态和时间戳，status为空时使用待处理
+func (w *Workflow) Initialize(task *models.Task, status models.TaskStatus, now time.Time) error {
+	if status == "" {
+		status = models.TaskStatusPending
+	}
+	if !status.IsValid() {
+		return ErrUnknownStatus
+	}
+
+	task.Status = status
+	applyTimestamps(task, now)
+	return nil
+}
+
+// Transition 将任务流转到新状态并记录时间戳，to为空时保持原状态
+func (w *Workflow) Transition(task *models.Task, to models.TaskStatus, now time.Time) error {
+	if to == "" || to == task.Status {
+		return nil
+	}
+	if !to.IsValid() {
+		return ErrUnknownStatus
+	}
+	if !w.CanTransition(task.Status, to) {
+		return ErrInvalidTransition
+	}
+
+	task.Status = to
+	task.AutoDelayedAt = nil
+	applyTimestamps(task, now)
+	return nil
+}
+
+// applyTimestamps 根据任务当前状态更新开始和完成时间
+func applyTimestamps(task *models.Task, now time.Time) {
+	switch task.Status {
+	case models.TaskStatusInProcess:
+		if task.StartedAt == nil {
+			task.StartedAt = &now
+		}
+		task.CompletedAt = nil
+	case models.TaskStatusCompleted:
+		task.CompletedAt = &now
+	default:
+		task.CompletedAt = nil
+	}
+}
@@ -0,0 +1,146 @@
+package workflow
+
+import (
+	"errors"
+	"project_management/internal/models"
+	"testing"
+	"time"
+)
+
+func TestCanTransition(t *testing.T) {
+	w := &Workflow{transitions: DefaultTransitions}
+
+	tests := []struct {
+		from, to models.TaskStatus
+		want     bool
+	}{
+		{models.TaskStatusPending, models.TaskStatusInProcess, true},
+		{models.TaskStatusPending, models.TaskStatusCompleted, true},
+		{models.TaskStatusInProcess, models.TaskStatusDelayed, true},
+		{models.TaskStatusDelayed, models.TaskStatusCompleted, true},
+		{models.TaskStatusCompleted, models.TaskStatusInProcess, true},
+		{models.TaskStatusCompleted, models.TaskStatusPending, false},
+		{models.TaskStatusCompleted, models.TaskStatusDelayed, false},
+		{models.TaskStatusCompleted, models.TaskStatusCompleted, true},
+		{models.TaskStatusPending, "未知", false},
+		{"未知", models.TaskStatusPending, false},
+	}
+	for _, tt := range tests {
+		if got := w.CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestTransition(t *testing.T) {
+	w := &Workflow{transitions: DefaultTransitions}
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	earlier := now.Add(-24 * time.Hour)
+
+	tests := []struct {
+		name          string
+		task          models.Task
+		to            models.TaskStatus
+		wantErr       error
+		wantStatus    models.TaskStatus
+		wantStarted   *time.Time
+		wantCompleted *time.Time
+	}{
+		{
+			name:        "开始任务记录开始时间",
+			task:        models.Task{Status: models.TaskStatusPending},
+			to:          models.TaskStatusInProcess,
+			wantStatus:  models.TaskStatusInProcess,
+			wantStarted: &now,
+		},
+		{
+			name:        "再次开始保留首次开始时间",
+			task:        models.Task{Status: models.TaskStatusDelayed, StartedAt: &earlier},
+			to:          models.TaskStatusInProcess,
+			wantStatus:  models.TaskStatusInProcess,
+			wantStarted: &earlier,
+		},
+		{
+			name:          "完成任务记录完成时间",
+			task:          models.Task{Status: models.TaskStatusInProcess, StartedAt: &earlier},
+			to:            models.TaskStatusCompleted,
+			wantStatus:    models.TaskStatusCompleted,
+			wantStarted:   &earlier,
+			wantCompleted: &now,
+		},
+		{
+			name:        "重新打开清空完成时间",
+			task:        models.Task{Status: models.TaskStatusCompleted, StartedAt: &earlier, CompletedAt: &earlier},
+			to:          models.TaskStatusInProcess,
+			wantStatus:  models.TaskStatusInProcess,
+			wantStarted: &earlier,
+		},
+		{
+			name:          "目标状态为空时保持不变",
+			task:          models.Task{Status: models.TaskStatusCompleted, CompletedAt: &earlier},
+			to:            "",
+			wantStatus:    models.TaskStatusCompleted,
+			wantCompleted: &earlier,
+		},
+		{
+			name:          "不允许的状态变更",
+			task:          models.Task{Status: models.TaskStatusCompleted, CompletedAt: &earlier},
+			to:            models.TaskStatusPending,
+			wantErr:       ErrInvalidTransition,
+			wantStatus:    models.TaskStatusCompleted,
+			wantCompleted: &earlier,
+		},
+		{
+			name:       "未知状态",
+			task:       models.Task{Status: models.TaskStatusPending},
+			to:         "未知",
+			wantErr:    ErrUnknownStatus,
+			wantStatus: models.TaskStatusPending,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := tt.task
+			err := w.Transition(&task, tt.to, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Transition() error = %v, want %v", err, tt.wantErr)
+			}
+			if task.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", task.Status, tt.wantStatus)
+			}
+			if !sameTime(task.StartedAt, tt.wantStarted) {
+				t.Errorf("StartedAt = %v, want %v", task.StartedAt, tt.wantStarted)
+			}
+			if !sameTime(task.CompletedAt, tt.wantCompleted) {
+				t.Errorf("CompletedAt = %v, want %v", task.CompletedAt, tt.wantCompleted)
+			}
+		})
+	}
+}
+
+func TestTransitionClearsAutoDelayed(t *testing.T) {
+	w := &Workflow{transitions: DefaultTransitions}
+	delayedAt := time.Now().Add(-time.Hour)
+	task := models.Task{Status: models.TaskStatusDelayed, AutoDelayedAt: &delayedAt}
+
+	if err := w.Transition(&task, models.TaskStatusPending, time.Now()); err != nil {
+		t.Fatalf("Transition() error = %v", err)
+	}
+	if task.AutoDelayedAt != nil {
+		t.Errorf("AutoDelayedAt = %v, want nil", task.AutoDelayedAt)
+	}
+}
+
+func TestNewRejectsUnknownStatus(t *testing.T) {
+	_, err := New(map[models.TaskStatus][]models.TaskStatus{models.TaskStatusPending: {"完成了"}})
+	if !errors.Is(err, ErrUnknownStatus) {
+		t.Fatalf("New() error = %v, want %v", err, ErrUnknownStatus)
+	}
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
@@ -133,6 +133,19 @@ export const apiRequest = async (
   }
 };
 
+// 获取分页列表的所有数据，逐页请求直到取完total条
+export const fetchAllPages = async (endpoint: string) => {
+  const items: any[] = [];
+  for (let page = 1; ; page++) {
+    const separator = endpoint.includes('?') ? '&' : '?';
+    const data = await apiRequest(`${endpoint}${separator}page=${page}&page_size=100`);
+    items.push(...(data.items || []));
+    if (!data.items || data.items.length === 0 || items.length >= data.total) {
+      return items;
+    }
+  }
+};
+
 // 认证API
 export const authAPI = {
   login: async (username: string, password: string) => {
@@ -157,7 +170,7 @@ export const authAPI = {
 // 任务API
 export const taskAPI = {
   getAll: async () => {
-    return apiRequest('/tasks');
+    return fetchAllPages('/tasks');
   },
   
   getById: async (id: number) => {
@@ -180,7 +193,7 @@ export const taskAPI = {
 // 里程碑API
 export const milestoneAPI = {
   getAll: async () => {
-    return apiRequest('/milestones');
+    return fetchAllPages('/milestones');
   },
   
   getById: async (id: number) => {